@@ -1,24 +1,211 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"expvar"
+	"flag"
 	"fmt"
-	"net/http"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/gorilla/mux"
+	"github.com/hoaibao/book-management/pkg/config"
+	"github.com/hoaibao/book-management/pkg/database"
+	"github.com/hoaibao/book-management/pkg/database/migration"
 	"github.com/hoaibao/book-management/pkg/handler"
+	"github.com/hoaibao/book-management/pkg/health"
+	"github.com/hoaibao/book-management/pkg/logger"
+	"github.com/hoaibao/book-management/pkg/middleware"
 	"github.com/hoaibao/book-management/pkg/repository"
 	"github.com/hoaibao/book-management/pkg/router"
+	"github.com/hoaibao/book-management/pkg/server"
 	"github.com/hoaibao/book-management/pkg/service"
 )
 
+const usage = "Usage: book-management [flags] [migrate up | down | goto <version> | force <version> | status | purge]"
+
 func main() {
+	cfg, args, err := config.Load(os.Args[1:], os.Stderr, usage)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	appLogger := logger.InitLogger(cfg.Log)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if len(args) > 0 && args[0] == "migrate" {
+		db, migrator, err := openDatabase(ctx, cfg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer db.Close()
+		defer appLogger.Close()
+		if err := runMigrate(ctx, migrator, args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(args) > 0 && args[0] == "purge" {
+		db, _, err := openDatabase(ctx, cfg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer db.Close()
+		defer appLogger.Close()
+		_, sqlBookRepository := newSQLRepositories(cfg, db, appLogger)
+		bookService := service.NewBookService(sqlBookRepository)
+		purged, err := bookService.PurgeDeletedBooks(ctx, cfg.TrashRetention)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("Purged", purged, "deleted books older than", cfg.TrashRetention)
+		return
+	}
+
+	fmt.Print("Configuration:\n", cfg)
+
+	var db *sql.DB
+	var migrator *migration.Migrator
+	var authorRepository repository.AuthorRepository
+	var bookRepository repository.BookRepository
+	if cfg.Storage == config.StorageMemory {
+		inMemoryAuthorRepository := repository.NewInMemoryAuthorRepository()
+		authorRepository = inMemoryAuthorRepository
+		bookRepository = repository.NewInMemoryBookRepository(inMemoryAuthorRepository)
+	} else {
+		db, migrator, err = openDatabase(ctx, cfg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := checkSchema(ctx, migrator, cfg.AutoMigrate); err != nil {
+			log.Fatal("Database schema is not ready, run the migrate command or start with -auto-migrate: ", err)
+		}
+		authorRepository, bookRepository = newSQLRepositories(cfg, db, appLogger)
+		expvar.Publish("dbPool", expvar.Func(func() any {
+			return db.Stats()
+		}))
+	}
+
+	if cfg.BookCache.Size > 0 {
+		cachedBookRepository := repository.NewCachedBookRepository(bookRepository, cfg.BookCache.Size, cfg.BookCache.TTL)
+		bookRepository = cachedBookRepository
+		authorRepository = repository.NewAuthorCacheInvalidator(authorRepository, cachedBookRepository)
+		expvar.Publish("bookCache", expvar.Func(func() any {
+			return cachedBookRepository.Stats()
+		}))
+	}
 
-	bookRepository := repository.NewMemoryBookRepository()
 	bookService := service.NewBookService(bookRepository)
 	bookHandler := handler.NewBookHandler(bookService)
+	authorService := service.NewAuthorService(authorRepository, bookRepository)
+	authorHandler := handler.NewAuthorHandler(authorService)
+
+	healthChecker := newHealthChecker(db, migrator, appLogger)
+	healthHandler := handler.NewHealthHandler(healthChecker)
 
 	mainRouter := router.SetMainRouter()
+	mainRouter.Use(middleware.Recovery(appLogger))
+	mainRouter.Use(middleware.Actor)
+	router.SetHealthRouter(healthHandler, mainRouter)
 	router.SetBookRouter(bookHandler, mainRouter)
+	router.SetAuthorRouter(authorHandler, mainRouter)
+
+	// Requests are logged outside of the router, so that the ones no route
+	// matches are logged too.
+	httpServer := server.New(cfg.HTTP, middleware.RequestID(middleware.Logging(appLogger)(mainRouter)))
+	healthChecker.Add("shutdown", func(context.Context) error {
+		if httpServer.ShuttingDown() {
+			return errors.New("server is shutting down")
+		}
+		return nil
+	})
+	if db != nil {
+		httpServer.OnShutdown(db.Close)
+	}
+	httpServer.OnShutdown(appLogger.Close)
+
+	if cfg.AdminAddr != "" {
+		// The variables include the command line and memory statistics, so
+		// they are served apart from the API.
+		adminRouter := mux.NewRouter()
+		adminRouter.Handle("/debug/vars", expvar.Handler()).Methods("GET")
+		adminConfig := cfg.HTTP
+		adminConfig.Addr = cfg.AdminAddr
+		adminServer := server.New(adminConfig, adminRouter)
+		go func() {
+			if err := adminServer.Run(ctx); err != nil && ctx.Err() == nil {
+				log.Fatal("Admin server: ", err)
+			}
+		}()
+		fmt.Println("Serving /debug/vars at", cfg.AdminAddr)
+	}
+
+	fmt.Println("Starting server at", cfg.HTTP.Addr, "with", cfg.Storage, "storage")
+	if err := httpServer.Run(ctx); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("Server stopped")
+}
+
+// newHealthChecker checks that the database answers and has the expected
+// schema, when there is one, and that the logs can be written.
+func newHealthChecker(db *sql.DB, migrator *migration.Migrator, appLogger logger.Logger) *health.Checker {
+	checker := health.NewChecker()
+	if db != nil {
+		checker.Add("database", db.PingContext)
+		checker.Add("migrations", migrator.Check)
+	}
+	checker.Add("logs", func(context.Context) error {
+		return appLogger.Writable()
+	})
+	return checker
+}
+
+// openDatabase connects to the postgres or sqlite database and returns its
+// migrator. The repositories share the returned connection pool.
+func openDatabase(ctx context.Context, cfg *config.Config) (*sql.DB, *migration.Migrator, error) {
+	var db *sql.DB
+	var err error
+	switch cfg.Storage {
+	case config.StoragePostgres:
+		db, err = database.NewConnection(ctx, &cfg.DB)
+		if err != nil {
+			return nil, nil, err
+		}
+	case config.StorageSQLite:
+		db, err = database.NewSQLiteConnection(cfg.SQLitePath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("can't open sqlite database %s: %w", cfg.SQLitePath, err)
+		}
+		database.ConfigurePool(db, &cfg.DB)
+	default:
+		return nil, nil, fmt.Errorf("unknown SQL storage %q, expected postgres or sqlite", cfg.Storage)
+	}
+
+	newMigrator := migration.NewMigrator
+	if cfg.Storage == config.StorageSQLite {
+		newMigrator = migration.NewSQLiteMigrator
+	}
+	migrator, err := newMigrator(db)
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	return db, migrator, nil
+}
 
-	fmt.Println("Starting server at port 8080")
-	http.ListenAndServe(":8080", mainRouter)
+func newSQLRepositories(cfg *config.Config, db *sql.DB, appLogger logger.Logger) (*repository.SQLAuthorRepository, *repository.SQLBookRepository) {
+	authorRepository := repository.NewSQLAuthorRepository(db, appLogger)
+	if cfg.Storage == config.StorageSQLite {
+		return authorRepository, repository.NewSQLiteBookRepository(db, authorRepository, appLogger)
+	}
+	return authorRepository, repository.NewPostgresBookRepository(db, authorRepository, appLogger)
 }
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/hoaibao/book-management/pkg/database/migration"
+)
+
+const migrateUsage = "usage: migrate up | down | goto <version> | force <version> | status"
+
+// runMigrate runs the migrate sub command with its arguments.
+func runMigrate(ctx context.Context, migrator *migration.Migrator, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	var err error
+	switch args[0] {
+	case "up":
+		err = migrator.Up(ctx)
+	case "down":
+		err = migrator.Down(ctx)
+	case "goto", "force":
+		if len(args) != 2 {
+			return errors.New(migrateUsage)
+		}
+		version, parseErr := strconv.ParseUint(args[1], 10, 32)
+		if parseErr != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		if args[0] == "goto" {
+			err = migrator.Goto(ctx, uint(version))
+		} else {
+			err = migrator.Force(ctx, uint(version))
+		}
+	case "status":
+	default:
+		return errors.New(migrateUsage)
+	}
+	if errors.Is(err, migration.ErrNoChange) {
+		fmt.Println("No change")
+	} else if err != nil {
+		return err
+	}
+
+	return printMigrationStatus(ctx, migrator)
+}
+
+func printMigrationStatus(ctx context.Context, migrator *migration.Migrator) error {
+	statuses, version, dirty, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+	for _, status := range statuses {
+		applied := " "
+		if status.Applied {
+			applied = "x"
+		}
+		fmt.Printf("[%s] %03d %s\n", applied, status.Version, status.Name)
+	}
+	fmt.Printf("Current version: %d, dirty: %t\n", version, dirty)
+	return nil
+}
+
+// checkSchema makes sure the database is at the version the code expects,
+// migrating it first when autoMigrate is set.
+func checkSchema(ctx context.Context, migrator *migration.Migrator, autoMigrate bool) error {
+	if autoMigrate {
+		if err := migrator.Up(ctx); err != nil && !errors.Is(err, migration.ErrNoChange) {
+			return err
+		}
+	}
+	return migrator.Check(ctx)
+}
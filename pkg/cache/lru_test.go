@@ -0,0 +1,149 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRUEviction(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxSize   int
+		run       func(c *LRU[string, int])
+		present   []string
+		absent    []string
+		evictions uint64
+	}{
+		{
+			name:    "least recently set",
+			maxSize: 2,
+			run: func(c *LRU[string, int]) {
+				c.Set("a", 1)
+				c.Set("b", 2)
+				c.Set("c", 3)
+			},
+			present:   []string{"b", "c"},
+			absent:    []string{"a"},
+			evictions: 1,
+		},
+		{
+			name:    "get refreshes an entry",
+			maxSize: 2,
+			run: func(c *LRU[string, int]) {
+				c.Set("a", 1)
+				c.Set("b", 2)
+				c.Get("a")
+				c.Set("c", 3)
+			},
+			present:   []string{"a", "c"},
+			absent:    []string{"b"},
+			evictions: 1,
+		},
+		{
+			name:    "set replaces without evicting",
+			maxSize: 2,
+			run: func(c *LRU[string, int]) {
+				c.Set("a", 1)
+				c.Set("b", 2)
+				c.Set("a", 10)
+			},
+			present: []string{"a", "b"},
+		},
+		{
+			name:    "delete",
+			maxSize: 2,
+			run: func(c *LRU[string, int]) {
+				c.Set("a", 1)
+				c.Set("b", 2)
+				c.Delete("a")
+			},
+			present: []string{"b"},
+			absent:  []string{"a"},
+		},
+		{
+			name:    "delete func",
+			maxSize: 3,
+			run: func(c *LRU[string, int]) {
+				c.Set("a", 1)
+				c.Set("b", 2)
+				c.Set("c", 3)
+				c.DeleteFunc(func(_ string, value int) bool { return value%2 == 1 })
+			},
+			present: []string{"b"},
+			absent:  []string{"a", "c"},
+		},
+		{
+			name:    "zero size disables the cache",
+			maxSize: 0,
+			run: func(c *LRU[string, int]) {
+				c.Set("a", 1)
+			},
+			absent: []string{"a"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := NewLRU[string, int](test.maxSize, 0)
+			test.run(c)
+			if evictions := c.Stats().Evictions; evictions != test.evictions {
+				t.Errorf("evictions = %d, want %d", evictions, test.evictions)
+			}
+			for _, key := range test.present {
+				if _, ok := c.Get(key); !ok {
+					t.Errorf("Get(%q) missed", key)
+				}
+			}
+			for _, key := range test.absent {
+				if value, ok := c.Get(key); ok {
+					t.Errorf("Get(%q) = %d, want a miss", key, value)
+				}
+			}
+			if size := c.Stats().Size; size != len(test.present) {
+				t.Errorf("size = %d, want %d", size, len(test.present))
+			}
+		})
+	}
+}
+
+func TestLRUTTL(t *testing.T) {
+	tests := []struct {
+		name    string
+		ttl     time.Duration
+		wait    time.Duration
+		present bool
+	}{
+		{name: "fresh", ttl: time.Hour, present: true},
+		{name: "expired", ttl: time.Millisecond, wait: 10 * time.Millisecond},
+		{name: "no ttl", ttl: 0, wait: 10 * time.Millisecond, present: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := NewLRU[string, int](10, test.ttl)
+			c.Set("a", 1)
+			time.Sleep(test.wait)
+			value, ok := c.Get("a")
+			if ok != test.present {
+				t.Fatalf("Get() hit = %t, want %t", ok, test.present)
+			}
+			if ok && value != 1 {
+				t.Errorf("Get() = %d, want 1", value)
+			}
+			if !ok && c.Stats().Size != 0 {
+				t.Errorf("size = %d, want the expired entry removed", c.Stats().Size)
+			}
+		})
+	}
+}
+
+func TestLRUStats(t *testing.T) {
+	c := NewLRU[string, int](1, 0)
+	c.Set("a", 1)
+	c.Get("a")
+	c.Get("b")
+	c.Set("b", 2)
+
+	want := Stats{Hits: 1, Misses: 1, Evictions: 1, Size: 1, MaxSize: 1}
+	if stats := c.Stats(); stats != want {
+		t.Errorf("Stats() = %+v, want %+v", stats, want)
+	}
+}
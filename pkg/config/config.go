@@ -0,0 +1,316 @@
+// Package config gathers the settings of the server. Every setting has a
+// key, read from the config file and from the environment, and most have a
+// command line flag. Flags override the environment, which overrides the
+// file, which overrides the defaults.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hoaibao/book-management/pkg/database"
+	"github.com/hoaibao/book-management/pkg/logger"
+	"github.com/hoaibao/book-management/pkg/server"
+	goDotEnv "github.com/joho/godotenv"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	StoragePostgres = "postgres"
+	StorageSQLite   = "sqlite"
+	StorageMemory   = "memory"
+)
+
+// DefaultFile is read when no -config flag is given. Unlike a file named by
+// the flag, it may be missing.
+const DefaultFile = ".env"
+
+const redacted = "******"
+
+type Config struct {
+	Storage     string
+	SQLitePath  string
+	AutoMigrate bool
+	DB          database.Config
+	HTTP        server.Config
+	// AdminAddr is the address of the listener serving /debug/vars, kept
+	// apart from the API. Empty disables it.
+	AdminAddr string
+	Log       logger.Config
+	// TrashRetention is how long deleted books stay in the trash before the
+	// purge command removes them.
+	TrashRetention time.Duration
+	BookCache      CacheConfig
+}
+
+type CacheConfig struct {
+	Size int
+	TTL  time.Duration
+}
+
+// setting binds a key and a flag to a field of Config.
+type setting struct {
+	key    string
+	flag   string
+	usage  string
+	secret bool
+	value  flag.Value
+}
+
+func Default() *Config {
+	return &Config{
+		Storage:    StoragePostgres,
+		SQLitePath: "book_management.db",
+		DB: database.Config{
+			Host:            "localhost",
+			Port:            "5432",
+			SSLMode:         "disable",
+			MaxOpenConns:    25,
+			MaxIdleConns:    25,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+
+			ConnectTimeout:    30 * time.Second,
+			ConnectBackoff:    500 * time.Millisecond,
+			ConnectMaxBackoff: 5 * time.Second,
+		},
+		HTTP: server.Config{
+			Addr:              ":8080",
+			RequestTimeout:    10 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       15 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       time.Minute,
+			ShutdownTimeout:   15 * time.Second,
+			MaxHeaderBytes:    1 << 20,
+			MaxBodyBytes:      1 << 20,
+		},
+		Log: logger.Config{
+			Dir:            defaultLogDir(),
+			Level:          "info",
+			MaxSizeMB:      100,
+			RotateInterval: 24 * time.Hour,
+			MaxBackups:     14,
+			MaxAgeDays:     30,
+			Compress:       true,
+		},
+		TrashRetention: 30 * 24 * time.Hour,
+		BookCache: CacheConfig{
+			Size: 1000,
+			TTL:  time.Minute,
+		},
+	}
+}
+
+func (c *Config) settings() []setting {
+	return []setting{
+		{key: "STORAGE", flag: "storage", usage: "storage backend: postgres, sqlite or memory", value: (*stringValue)(&c.Storage)},
+		{key: "SQLITE_PATH", flag: "sqlite-path", usage: "database file of the sqlite storage", value: (*stringValue)(&c.SQLitePath)},
+		{key: "AUTO_MIGRATE", flag: "auto-migrate", usage: "migrate the database to the expected schema version at startup", value: (*boolValue)(&c.AutoMigrate)},
+
+		{key: "DB_HOST", flag: "db-host", usage: "postgres host", value: (*stringValue)(&c.DB.Host)},
+		{key: "DB_PORT", flag: "db-port", usage: "postgres port", value: (*stringValue)(&c.DB.Port)},
+		{key: "DB_USER", flag: "db-user", usage: "postgres user", value: (*stringValue)(&c.DB.User)},
+		// The password has no flag, so that it doesn't show up in the process list.
+		{key: "DB_PASSWORD", secret: true, value: (*stringValue)(&c.DB.Password)},
+		{key: "DB_NAME", flag: "db-name", usage: "postgres database", value: (*stringValue)(&c.DB.DBName)},
+		{key: "DB_SSL_MODE", flag: "db-ssl-mode", usage: "postgres sslmode", value: (*stringValue)(&c.DB.SSLMode)},
+		{key: "DB_MAX_OPEN_CONNS", flag: "db-max-open-conns", usage: "maximum number of open database connections, 0 for no limit", value: (*intValue)(&c.DB.MaxOpenConns)},
+		{key: "DB_MAX_IDLE_CONNS", flag: "db-max-idle-conns", usage: "maximum number of idle database connections", value: (*intValue)(&c.DB.MaxIdleConns)},
+		{key: "DB_CONN_MAX_LIFETIME", flag: "db-conn-max-lifetime", usage: "how long a database connection is reused, 0 for ever", value: (*durationValue)(&c.DB.ConnMaxLifetime)},
+		{key: "DB_CONN_MAX_IDLE_TIME", flag: "db-conn-max-idle-time", usage: "how long a database connection stays idle before it is closed, 0 for ever", value: (*durationValue)(&c.DB.ConnMaxIdleTime)},
+		{key: "DB_CONNECT_TIMEOUT", flag: "db-connect-timeout", usage: "how long to wait for the database at startup, retries included", value: (*durationValue)(&c.DB.ConnectTimeout)},
+		{key: "DB_CONNECT_BACKOFF", flag: "db-connect-backoff", usage: "wait after the first failed connection attempt, doubled after each attempt", value: (*durationValue)(&c.DB.ConnectBackoff)},
+		{key: "DB_CONNECT_MAX_BACKOFF", flag: "db-connect-max-backoff", usage: "longest wait between connection attempts", value: (*durationValue)(&c.DB.ConnectMaxBackoff)},
+
+		{key: "HTTP_ADDR", flag: "http-addr", usage: "address the server listens on", value: (*stringValue)(&c.HTTP.Addr)},
+		{key: "REQUEST_TIMEOUT", flag: "request-timeout", usage: "deadline for each request, 0 to disable", value: (*durationValue)(&c.HTTP.RequestTimeout)},
+		{key: "HTTP_READ_HEADER_TIMEOUT", flag: "http-read-header-timeout", usage: "how long reading the request headers may take, 0 for no limit", value: (*durationValue)(&c.HTTP.ReadHeaderTimeout)},
+		{key: "HTTP_READ_TIMEOUT", flag: "http-read-timeout", usage: "how long reading a whole request may take, 0 for no limit", value: (*durationValue)(&c.HTTP.ReadTimeout)},
+		{key: "HTTP_WRITE_TIMEOUT", flag: "http-write-timeout", usage: "how long writing a response may take, 0 for no limit", value: (*durationValue)(&c.HTTP.WriteTimeout)},
+		{key: "HTTP_IDLE_TIMEOUT", flag: "http-idle-timeout", usage: "how long an idle keep-alive connection stays open, 0 for the read timeout", value: (*durationValue)(&c.HTTP.IdleTimeout)},
+		{key: "HTTP_SHUTDOWN_DELAY", flag: "http-shutdown-delay", usage: "how long the server keeps serving, reporting not ready, once asked to stop", value: (*durationValue)(&c.HTTP.ShutdownDelay)},
+		{key: "HTTP_SHUTDOWN_TIMEOUT", flag: "http-shutdown-timeout", usage: "how long in-flight requests may run once the server is stopping", value: (*durationValue)(&c.HTTP.ShutdownTimeout)},
+		{key: "HTTP_MAX_HEADER_BYTES", flag: "http-max-header-bytes", usage: "maximum size of the request headers", value: (*intValue)(&c.HTTP.MaxHeaderBytes)},
+		{key: "HTTP_MAX_BODY_BYTES", flag: "http-max-body-bytes", usage: "maximum size of a request body, 0 for no limit", value: (*intValue)(&c.HTTP.MaxBodyBytes)},
+		{key: "ADMIN_ADDR", flag: "admin-addr", usage: "address serving /debug/vars, empty to disable it", value: (*stringValue)(&c.AdminAddr)},
+
+		{key: "LOG_DIR", flag: "log-dir", usage: "directory of the log files", value: (*stringValue)(&c.Log.Dir)},
+		{key: "LOG_LEVEL", flag: "log-level", usage: "minimum level logged: debug, info, warn or error", value: (*stringValue)(&c.Log.Level)},
+		{key: "LOG_MAX_SIZE_MB", flag: "log-max-size-mb", usage: "size in megabytes at which the log file is rotated", value: (*intValue)(&c.Log.MaxSizeMB)},
+		{key: "LOG_ROTATE_INTERVAL", flag: "log-rotate-interval", usage: "rotate the log file periodically, 0 to rotate by size only", value: (*durationValue)(&c.Log.RotateInterval)},
+		{key: "LOG_MAX_BACKUPS", flag: "log-max-backups", usage: "number of rotated log files kept, 0 for no limit", value: (*intValue)(&c.Log.MaxBackups)},
+		{key: "LOG_MAX_AGE_DAYS", flag: "log-max-age-days", usage: "days a rotated log file is kept, 0 for no limit", value: (*intValue)(&c.Log.MaxAgeDays)},
+		{key: "LOG_COMPRESS", flag: "log-compress", usage: "gzip the rotated log files", value: (*boolValue)(&c.Log.Compress)},
+
+		{key: "TRASH_RETENTION", flag: "trash-retention", usage: "how long deleted books stay in the trash before purge removes them", value: (*durationValue)(&c.TrashRetention)},
+		{key: "BOOK_CACHE_SIZE", flag: "book-cache-size", usage: "number of books kept in the cache, 0 to disable it", value: (*intValue)(&c.BookCache.Size)},
+		{key: "BOOK_CACHE_TTL", flag: "book-cache-ttl", usage: "how long a book stays in the cache, 0 for no expiry", value: (*durationValue)(&c.BookCache.TTL)},
+	}
+}
+
+// Load builds the configuration from the file named by the -config flag,
+// the environment and the flags in args, and validates it. It returns the
+// arguments left after the flags. flag.ErrHelp is returned when args ask
+// for the usage, which has then been printed to output.
+func Load(args []string, output io.Writer, usage string) (*Config, []string, error) {
+	// The file is named by a flag, so the flags are parsed once to find it,
+	// and again on top of the file and the environment.
+	probe := Default()
+	probeFlags, configFile := probe.flagSet(io.Discard, "")
+	probeFlags.Parse(args)
+	fileRequired := false
+	probeFlags.Visit(func(f *flag.Flag) {
+		fileRequired = fileRequired || f.Name == "config"
+	})
+
+	config := Default()
+	values, err := goDotEnv.Read(*configFile)
+	if err != nil && (fileRequired || !errors.Is(err, fs.ErrNotExist)) {
+		return nil, nil, fmt.Errorf("config: %w", err)
+	}
+	if err := config.apply(values, *configFile); err != nil {
+		return nil, nil, err
+	}
+	if err := config.apply(environment(config.settings()), "environment"); err != nil {
+		return nil, nil, err
+	}
+
+	flags, _ := config.flagSet(output, usage)
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, nil, err
+	}
+	return config, flags.Args(), nil
+}
+
+func (c *Config) flagSet(output io.Writer, usage string) (*flag.FlagSet, *string) {
+	flags := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	flags.SetOutput(output)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+	configFile := flags.String("config", DefaultFile, "file of KEY=value settings, overridden by environment variables and flags")
+	for _, s := range c.settings() {
+		if s.flag != "" {
+			flags.Var(s.value, s.flag, fmt.Sprintf("%s (%s)", s.usage, s.key))
+		}
+	}
+	return flags, configFile
+}
+
+// apply sets the settings found in values, which come from source.
+func (c *Config) apply(values map[string]string, source string) error {
+	for _, s := range c.settings() {
+		value, exist := values[s.key]
+		if !exist {
+			continue
+		}
+		if err := s.value.Set(value); err != nil {
+			return fmt.Errorf("config: %s in %s: %w", s.key, source, err)
+		}
+	}
+	return nil
+}
+
+func environment(settings []setting) map[string]string {
+	values := make(map[string]string)
+	for _, s := range settings {
+		if value, exist := os.LookupEnv(s.key); exist {
+			values[s.key] = value
+		}
+	}
+	return values
+}
+
+// Validate reports every missing or invalid setting at once.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, key, message string) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("config: %s %s", key, message))
+		}
+	}
+
+	switch c.Storage {
+	case StoragePostgres:
+		check(c.DB.Host != "", "DB_HOST", "is required")
+		check(c.DB.Port != "", "DB_PORT", "is required")
+		check(c.DB.User != "", "DB_USER", "is required")
+		check(c.DB.DBName != "", "DB_NAME", "is required")
+	case StorageSQLite:
+		check(c.SQLitePath != "", "SQLITE_PATH", "is required")
+	case StorageMemory:
+	default:
+		check(false, "STORAGE", fmt.Sprintf("must be %s, %s or %s, not %q", StoragePostgres, StorageSQLite, StorageMemory, c.Storage))
+	}
+	check(c.DB.MaxOpenConns >= 0, "DB_MAX_OPEN_CONNS", "can't be negative")
+	check(c.DB.MaxIdleConns >= 0, "DB_MAX_IDLE_CONNS", "can't be negative")
+	check(c.DB.ConnMaxLifetime >= 0, "DB_CONN_MAX_LIFETIME", "can't be negative")
+	check(c.DB.ConnMaxIdleTime >= 0, "DB_CONN_MAX_IDLE_TIME", "can't be negative")
+	check(c.DB.ConnectTimeout > 0, "DB_CONNECT_TIMEOUT", "must be positive")
+	check(c.DB.ConnectBackoff > 0, "DB_CONNECT_BACKOFF", "must be positive")
+	check(c.DB.ConnectMaxBackoff >= c.DB.ConnectBackoff, "DB_CONNECT_MAX_BACKOFF", "can't be shorter than DB_CONNECT_BACKOFF")
+
+	check(c.HTTP.Addr != "", "HTTP_ADDR", "is required")
+	check(c.HTTP.RequestTimeout >= 0, "REQUEST_TIMEOUT", "can't be negative")
+	check(c.HTTP.ReadHeaderTimeout >= 0, "HTTP_READ_HEADER_TIMEOUT", "can't be negative")
+	check(c.HTTP.ReadTimeout >= 0, "HTTP_READ_TIMEOUT", "can't be negative")
+	check(c.HTTP.WriteTimeout >= 0, "HTTP_WRITE_TIMEOUT", "can't be negative")
+	check(c.HTTP.IdleTimeout >= 0, "HTTP_IDLE_TIMEOUT", "can't be negative")
+	check(c.HTTP.ShutdownDelay >= 0, "HTTP_SHUTDOWN_DELAY", "can't be negative")
+	check(c.HTTP.ShutdownTimeout > 0, "HTTP_SHUTDOWN_TIMEOUT", "must be positive")
+	check(c.HTTP.MaxHeaderBytes > 0, "HTTP_MAX_HEADER_BYTES", "must be positive")
+	check(c.HTTP.MaxBodyBytes >= 0, "HTTP_MAX_BODY_BYTES", "can't be negative")
+	// A response written after the write timeout is lost, so the request
+	// deadline must come first for timed out requests to get their 504.
+	check(c.HTTP.WriteTimeout == 0 || c.HTTP.RequestTimeout < c.HTTP.WriteTimeout, "REQUEST_TIMEOUT", "must be shorter than HTTP_WRITE_TIMEOUT")
+	check(c.AdminAddr != c.HTTP.Addr, "ADMIN_ADDR", "must differ from HTTP_ADDR")
+
+	check(c.Log.Dir != "", "LOG_DIR", "is required")
+	_, err := zapcore.ParseLevel(c.Log.Level)
+	check(err == nil, "LOG_LEVEL", fmt.Sprintf("must be debug, info, warn or error, not %q", c.Log.Level))
+	check(c.Log.MaxSizeMB > 0, "LOG_MAX_SIZE_MB", "must be positive")
+	check(c.Log.RotateInterval == 0 || c.Log.RotateInterval >= time.Minute, "LOG_ROTATE_INTERVAL", "must be 0 or at least 1m")
+	check(c.Log.MaxBackups >= 0, "LOG_MAX_BACKUPS", "can't be negative")
+	check(c.Log.MaxAgeDays >= 0, "LOG_MAX_AGE_DAYS", "can't be negative")
+
+	check(c.TrashRetention >= 0, "TRASH_RETENTION", "can't be negative")
+	check(c.BookCache.Size >= 0, "BOOK_CACHE_SIZE", "can't be negative")
+	check(c.BookCache.TTL >= 0, "BOOK_CACHE_TTL", "can't be negative")
+	return errors.Join(errs...)
+}
+
+// defaultLogDir keeps the logs out of the working directory, which is often
+// the source tree, in the cache directory of the user.
+func defaultLogDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "book-management", "logs")
+}
+
+// String lists every setting as KEY=value, with secrets redacted, so the
+// configuration can be logged.
+func (c *Config) String() string {
+	var builder strings.Builder
+	for _, s := range c.settings() {
+		value := s.value.String()
+		if s.secret && value != "" {
+			value = redacted
+		}
+		fmt.Fprintf(&builder, "%s=%s\n", s.key, value)
+	}
+	return builder.String()
+}
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clearEnvironment unsets every setting for the duration of the test, so
+// that the environment of the machine running it doesn't leak in.
+func clearEnvironment(t *testing.T) {
+	for _, s := range Default().settings() {
+		if _, exist := os.LookupEnv(s.key); exist {
+			t.Setenv(s.key, "")
+			os.Unsetenv(s.key)
+		}
+	}
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "settings.env")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	clearEnvironment(t)
+	file := writeFile(t, "STORAGE=sqlite\nSQLITE_PATH=file.db\nBOOK_CACHE_SIZE=5\nBOOK_CACHE_TTL=2m\nDB_PASSWORD=secret\n")
+	t.Setenv("SQLITE_PATH", "environment.db")
+	t.Setenv("BOOK_CACHE_SIZE", "7")
+
+	config, args, err := Load([]string{"-config", file, "-book-cache-size", "9", "migrate", "up"}, io.Discard, "")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	tests := []struct {
+		setting string
+		got     interface{}
+		want    interface{}
+	}{
+		{setting: "STORAGE from the file", got: config.Storage, want: StorageSQLite},
+		{setting: "DB_PASSWORD from the file", got: config.DB.Password, want: "secret"},
+		{setting: "BOOK_CACHE_TTL from the file", got: config.BookCache.TTL, want: 2 * time.Minute},
+		{setting: "SQLITE_PATH from the environment", got: config.SQLitePath, want: "environment.db"},
+		{setting: "BOOK_CACHE_SIZE from the flag", got: config.BookCache.Size, want: 9},
+		{setting: "TRASH_RETENTION by default", got: config.TrashRetention, want: Default().TrashRetention},
+	}
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("%s = %v, want %v", test.setting, test.got, test.want)
+		}
+	}
+	if want := []string{"migrate", "up"}; !reflect.DeepEqual(args, want) {
+		t.Errorf("args = %v, want %v", args, want)
+	}
+}
+
+func TestLoadWithoutDefaultFile(t *testing.T) {
+	clearEnvironment(t)
+	// The tests run in the package directory, which has no .env file.
+	config, _, err := Load([]string{"-storage", "memory"}, io.Discard, "")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if config.Storage != StorageMemory {
+		t.Errorf("STORAGE = %q, want %q", config.Storage, StorageMemory)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		env   map[string]string
+		args  []string
+		error string
+	}{
+		{name: "missing named file", args: []string{"-config", "missing.env"}, error: "missing.env"},
+		{name: "invalid file value", file: "STORAGE=memory\nBOOK_CACHE_TTL=soon\n", error: "BOOK_CACHE_TTL in"},
+		{name: "invalid environment value", env: map[string]string{"BOOK_CACHE_SIZE": "many"}, args: []string{"-storage", "memory"}, error: "BOOK_CACHE_SIZE in environment"},
+		{name: "invalid flag value", args: []string{"-storage", "memory", "-auto-migrate=maybe"}, error: "auto-migrate"},
+		{name: "unknown flag", args: []string{"-db-password", "secret"}, error: "db-password"},
+		{name: "invalid setting", args: []string{"-storage", "mongo"}, error: "STORAGE must be"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clearEnvironment(t)
+			for key, value := range test.env {
+				t.Setenv(key, value)
+			}
+			args := test.args
+			if test.file != "" {
+				args = append([]string{"-config", writeFile(t, test.file)}, args...)
+			}
+			_, _, err := Load(args, io.Discard, "")
+			if err == nil || !strings.Contains(err.Error(), test.error) {
+				t.Errorf("Load() error = %v, want it to mention %q", err, test.error)
+			}
+		})
+	}
+}
+
+func TestValidateReportsEverySetting(t *testing.T) {
+	config := Default()
+	config.BookCache.Size = -1
+	err := config.Validate()
+	for _, key := range []string{"DB_USER", "DB_NAME", "BOOK_CACHE_SIZE"} {
+		if err == nil || !strings.Contains(err.Error(), key) {
+			t.Errorf("Validate() error = %v, want it to mention %s", err, key)
+		}
+	}
+}
+
+func TestStringRedactsSecrets(t *testing.T) {
+	config := Default()
+	config.DB.User = "books"
+	config.DB.Password = "hunter2"
+	listed := config.String()
+	if strings.Contains(listed, "hunter2") || !strings.Contains(listed, "DB_PASSWORD="+redacted+"\n") {
+		t.Errorf("String() doesn't redact the password:\n%s", listed)
+	}
+	if !strings.Contains(listed, "DB_USER=books\n") {
+		t.Errorf("String() doesn't list DB_USER:\n%s", listed)
+	}
+
+	config.DB.Password = ""
+	if listed := config.String(); !strings.Contains(listed, "DB_PASSWORD=\n") {
+		t.Errorf("String() of an empty password isn't empty:\n%s", listed)
+	}
+}
@@ -0,0 +1,13 @@
+DROP TRIGGER IF EXISTS author_search_vector_update ON author;
+DROP TRIGGER IF EXISTS book_author_search_vector_update ON book_author;
+DROP TRIGGER IF EXISTS book_search_vector_update ON book;
+
+DROP FUNCTION IF EXISTS author_search_vector_trigger();
+DROP FUNCTION IF EXISTS book_author_search_vector_trigger();
+DROP FUNCTION IF EXISTS book_search_vector_trigger();
+DROP FUNCTION IF EXISTS refresh_book_search_vector(INT);
+
+DROP INDEX IF EXISTS book_search_vector_idx;
+
+ALTER TABLE book
+    DROP COLUMN search_vector;
@@ -0,0 +1,62 @@
+ALTER TABLE book
+    ADD COLUMN search_vector tsvector;
+
+CREATE OR REPLACE FUNCTION refresh_book_search_vector(target_book_id INT) RETURNS VOID AS $$
+BEGIN
+    UPDATE book
+        SET search_vector =
+            setweight(to_tsvector('english', book."name"), 'A') ||
+            setweight(to_tsvector('english', coalesce((
+                SELECT string_agg(a."name", ' ')
+                FROM book_author ba
+                JOIN author a ON a.id = ba.author_id
+                WHERE ba.book_id = book.id
+            ), '')), 'B')
+        WHERE id = target_book_id;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION book_search_vector_trigger() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM refresh_book_search_vector(NEW.id);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION book_author_search_vector_trigger() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        PERFORM refresh_book_search_vector(OLD.book_id);
+    ELSE
+        PERFORM refresh_book_search_vector(NEW.book_id);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION author_search_vector_trigger() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM refresh_book_search_vector(ba.book_id)
+        FROM book_author ba
+        WHERE ba.author_id = NEW.id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Only fire on name changes, so the UPDATE done by the refresh itself
+-- doesn't trigger it again.
+CREATE TRIGGER book_search_vector_update
+    AFTER INSERT OR UPDATE OF "name" ON book
+    FOR EACH ROW EXECUTE FUNCTION book_search_vector_trigger();
+
+CREATE TRIGGER book_author_search_vector_update
+    AFTER INSERT OR DELETE ON book_author
+    FOR EACH ROW EXECUTE FUNCTION book_author_search_vector_trigger();
+
+CREATE TRIGGER author_search_vector_update
+    AFTER UPDATE OF "name" ON author
+    FOR EACH ROW EXECUTE FUNCTION author_search_vector_trigger();
+
+SELECT refresh_book_search_vector(id) FROM book;
+
+CREATE INDEX book_search_vector_idx ON book USING GIN (search_vector);
@@ -0,0 +1,2 @@
+ALTER TABLE book
+    DROP CONSTRAINT IF EXISTS book_isbn_key;
@@ -0,0 +1,32 @@
+UPDATE book
+SET isbn = regexp_replace(upper(isbn), '[^0-9X]', '', 'g');
+
+-- Store ISBN-10 as ISBN-13: add the 978 prefix and recompute the check digit.
+UPDATE book
+SET isbn = '978' || left(isbn, 9) || ((10 - (
+        SELECT SUM(substr('978' || left(isbn, 9), i, 1)::INT * CASE WHEN i % 2 = 1 THEN 1 ELSE 3 END)
+        FROM generate_series(1, 12) AS i
+    ) % 10) % 10)::TEXT
+WHERE length(isbn) = 10;
+
+-- Merge books sharing an ISBN into the oldest one, keeping every author.
+INSERT INTO book_author (book_id, author_id)
+SELECT DISTINCT kept.id, ba.author_id
+FROM book_author ba
+JOIN book b ON b.id = ba.book_id
+JOIN book kept ON kept.isbn = b.isbn AND kept.id = (SELECT MIN(id) FROM book WHERE isbn = b.isbn)
+WHERE b.id <> kept.id
+    AND NOT EXISTS (
+        SELECT 1 FROM book_author k WHERE k.book_id = kept.id AND k.author_id = ba.author_id
+    );
+
+DELETE FROM book_author ba
+USING book b
+WHERE ba.book_id = b.id
+    AND b.id <> (SELECT MIN(id) FROM book WHERE isbn = b.isbn);
+
+DELETE FROM book b
+WHERE b.id <> (SELECT MIN(id) FROM book WHERE isbn = b.isbn);
+
+ALTER TABLE book
+    ADD CONSTRAINT book_isbn_key UNIQUE (isbn);
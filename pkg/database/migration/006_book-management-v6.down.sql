@@ -0,0 +1,15 @@
+-- Books still in the trash are deleted for good.
+DELETE FROM book_author
+WHERE book_id IN (SELECT id FROM book WHERE deleted_at IS NOT NULL);
+
+DELETE FROM book
+WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS book_deleted_at_idx;
+DROP INDEX IF EXISTS book_isbn_key;
+
+ALTER TABLE book
+    ADD CONSTRAINT book_isbn_key UNIQUE (isbn);
+
+ALTER TABLE book
+    DROP COLUMN deleted_at;
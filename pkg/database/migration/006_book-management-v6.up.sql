@@ -0,0 +1,10 @@
+ALTER TABLE book
+    ADD COLUMN deleted_at TIMESTAMPTZ;
+
+-- Books in the trash keep their ISBN, which a new book may reuse.
+ALTER TABLE book
+    DROP CONSTRAINT book_isbn_key;
+
+CREATE UNIQUE INDEX book_isbn_key ON book (isbn) WHERE deleted_at IS NULL;
+
+CREATE INDEX book_deleted_at_idx ON book (deleted_at) WHERE deleted_at IS NOT NULL;
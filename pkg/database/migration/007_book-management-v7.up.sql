@@ -0,0 +1,26 @@
+CREATE TABLE book_revision (
+    book_id INT NOT NULL REFERENCES book(id),
+    revision INT NOT NULL,
+    "action" VARCHAR(20) NOT NULL,
+    actor VARCHAR(200) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    snapshot JSONB NOT NULL,
+    PRIMARY KEY (book_id, revision)
+);
+
+-- Start the history of existing books from their current state.
+INSERT INTO book_revision (book_id, revision, "action", actor, snapshot)
+SELECT b.id, 1, 'create', 'migration', jsonb_build_object(
+    'id', b.id,
+    'name', b."name",
+    'isbn', b.isbn,
+    'publishYear', b.publish_year,
+    'authors', COALESCE((
+        SELECT jsonb_agg(jsonb_build_object('id', a.id, 'name', a."name", 'birthDay', a.birth_day) ORDER BY a.id)
+        FROM book_author ba
+        JOIN author a ON ba.author_id = a.id
+        WHERE ba.book_id = b.id
+    ), '[]'::jsonb),
+    'deletedAt', b.deleted_at
+)
+FROM book b;
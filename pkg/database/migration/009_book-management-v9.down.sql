@@ -0,0 +1,2 @@
+ALTER TABLE author
+    DROP CONSTRAINT IF EXISTS author_name_key;
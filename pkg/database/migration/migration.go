@@ -0,0 +1,412 @@
+// Package migration applies the SQL migrations of this directory, which are
+// embedded in the binary. PostgreSQL migrations are at the top level, SQLite
+// migrations in the sqlite directory. The applied version is kept in the same
+// schema_migrations table as the migrate CLI, so databases migrated with
+// either tool stay compatible.
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+//go:embed *.sql sqlite/*.sql
+var files embed.FS
+
+// lockId identifies the advisory lock that keeps two servers from migrating
+// the same database at once.
+const lockId = 4_262_019_201
+
+var (
+	ErrDirty           = errors.New("migration: database is dirty, fix it and force a version")
+	ErrUnknownVersion  = errors.New("migration: unknown version")
+	ErrNoChange        = errors.New("migration: no change")
+	ErrVersionMismatch = errors.New("migration: schema version mismatch")
+)
+
+var fileNamePattern = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Version uint   `json:"version"`
+	Name    string `json:"name"`
+	Applied bool   `json:"applied"`
+}
+
+// Load returns the embedded PostgreSQL migrations ordered by version.
+func Load() ([]Migration, error) {
+	return load(files)
+}
+
+// LoadSQLite returns the embedded SQLite migrations ordered by version.
+func LoadSQLite() ([]Migration, error) {
+	sqliteFiles, err := fs.Sub(files, "sqlite")
+	if err != nil {
+		return nil, err
+	}
+	return load(sqliteFiles)
+}
+
+func load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint]*Migration)
+	for _, entry := range entries {
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(match[1], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("migration: invalid version in %s: %w", entry.Name(), err)
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, exist := byVersion[uint(version)]
+		if !exist {
+			migration = &Migration{Version: uint(version), Name: match[2]}
+			byVersion[uint(version)] = migration
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Down == "" {
+			return nil, fmt.Errorf("migration: missing down file for version %d", migration.Version)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	// lock keeps other migrators off the database until unlock is called.
+	lock   func(ctx context.Context, conn *sql.Conn) error
+	unlock func(conn *sql.Conn)
+	// hasVersionTable reports whether schema_migrations exists, so that
+	// reading the version never changes the schema.
+	hasVersionTable func(ctx context.Context, conn *sql.Conn) (bool, error)
+}
+
+// NewMigrator migrates a PostgreSQL database.
+func NewMigrator(db *sql.DB) (*Migrator, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{
+		db:              db,
+		migrations:      migrations,
+		lock:            advisoryLock,
+		unlock:          advisoryUnlock,
+		hasVersionTable: hasPostgresVersionTable,
+	}, nil
+}
+
+// NewSQLiteMigrator migrates a SQLite database. SQLite has no advisory
+// locks, but each migration runs in a transaction holding the write lock, so
+// a concurrent migrator fails on the already applied schema and rolls back.
+func NewSQLiteMigrator(db *sql.DB) (*Migrator, error) {
+	migrations, err := LoadSQLite()
+	if err != nil {
+		return nil, err
+	}
+	noLock := func(context.Context, *sql.Conn) error { return nil }
+	return &Migrator{
+		db:              db,
+		migrations:      migrations,
+		lock:            noLock,
+		unlock:          func(*sql.Conn) {},
+		hasVersionTable: hasSQLiteVersionTable,
+	}, nil
+}
+
+// Version returns the current schema version, 0 when nothing is applied.
+// It only reads the database: a missing schema_migrations table is version 0.
+func (m *Migrator) Version(ctx context.Context) (uint, bool, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	defer conn.Close()
+
+	exists, err := m.hasVersionTable(ctx, conn)
+	if err != nil || !exists {
+		return 0, false, err
+	}
+	return readVersion(ctx, conn)
+}
+
+// Check reports ErrVersionMismatch unless the database is at the latest
+// version, clean.
+func (m *Migrator) Check(ctx context.Context) error {
+	version, dirty, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("%w (version %d)", ErrDirty, version)
+	}
+	if expected := m.latest(); version != expected {
+		return fmt.Errorf("%w: database is at version %d, expected %d", ErrVersionMismatch, version, expected)
+	}
+	return nil
+}
+
+// Status lists every known migration and whether it is applied.
+func (m *Migrator) Status(ctx context.Context) ([]Status, uint, bool, error) {
+	version, dirty, err := m.Version(ctx)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		statuses = append(statuses, Status{
+			Version: migration.Version,
+			Name:    migration.Name,
+			Applied: migration.Version <= version,
+		})
+	}
+	return statuses, version, dirty, nil
+}
+
+// Up applies every pending migration.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.Goto(ctx, m.latest())
+}
+
+// Down reverts the most recently applied migration.
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *sql.Conn, version uint) error {
+		if version == 0 {
+			return ErrNoChange
+		}
+		return m.migrate(ctx, conn, version, m.previous(version))
+	})
+}
+
+// Goto migrates up or down to version. Version 0 reverts every migration.
+func (m *Migrator) Goto(ctx context.Context, version uint) error {
+	if version != 0 && m.index(version) < 0 {
+		return fmt.Errorf("%w %d", ErrUnknownVersion, version)
+	}
+	return m.withLock(ctx, func(conn *sql.Conn, current uint) error {
+		if current == version {
+			return ErrNoChange
+		}
+		return m.migrate(ctx, conn, current, version)
+	})
+}
+
+// Force records version as applied and clears the dirty flag without
+// running any migration.
+func (m *Migrator) Force(ctx context.Context, version uint) error {
+	if version != 0 && m.index(version) < 0 {
+		return fmt.Errorf("%w %d", ErrUnknownVersion, version)
+	}
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := m.lock(ctx, conn); err != nil {
+		return err
+	}
+	defer m.unlock(conn)
+
+	if err := ensureVersionTable(ctx, conn); err != nil {
+		return err
+	}
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := writeVersion(ctx, tx, version); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn, version uint) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := m.lock(ctx, conn); err != nil {
+		return err
+	}
+	defer m.unlock(conn)
+
+	if err := ensureVersionTable(ctx, conn); err != nil {
+		return err
+	}
+	version, dirty, err := readVersion(ctx, conn)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("%w (version %d)", ErrDirty, version)
+	}
+	if version != 0 && m.index(version) < 0 {
+		return fmt.Errorf("%w %d applied to the database", ErrUnknownVersion, version)
+	}
+	return fn(conn, version)
+}
+
+// migrate runs the migrations between from and to one version at a time.
+// Each migration and its version update share a transaction, so a failed
+// migration leaves the database at the previous version.
+func (m *Migrator) migrate(ctx context.Context, conn *sql.Conn, from, to uint) error {
+	for from != to {
+		var script string
+		var next uint
+		if from < to {
+			migration := m.migrations[m.index(m.next(from))]
+			script, next = migration.Up, migration.Version
+		} else {
+			migration := m.migrations[m.index(from)]
+			script, next = migration.Down, m.previous(from)
+		}
+
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, script); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration: %d -> %d: %w", from, next, err)
+		}
+		if err := writeVersion(ctx, tx, next); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		from = next
+	}
+	return nil
+}
+
+func (m *Migrator) latest() uint {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+func (m *Migrator) index(version uint) int {
+	for i, migration := range m.migrations {
+		if migration.Version == version {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *Migrator) next(version uint) uint {
+	for _, migration := range m.migrations {
+		if migration.Version > version {
+			return migration.Version
+		}
+	}
+	return version
+}
+
+func (m *Migrator) previous(version uint) uint {
+	previous := uint(0)
+	for _, migration := range m.migrations {
+		if migration.Version >= version {
+			break
+		}
+		previous = migration.Version
+	}
+	return previous
+}
+
+func hasPostgresVersionTable(ctx context.Context, conn *sql.Conn) (bool, error) {
+	var exists bool
+	err := conn.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists)
+	return exists, err
+}
+
+func hasSQLiteVersionTable(ctx context.Context, conn *sql.Conn) (bool, error) {
+	var count int
+	err := conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'`).Scan(&count)
+	return count > 0, err
+}
+
+// ensureVersionTable creates schema_migrations for the commands that write
+// the version.
+func ensureVersionTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)`)
+	return err
+}
+
+func readVersion(ctx context.Context, conn *sql.Conn) (uint, bool, error) {
+	var version int64
+	var dirty bool
+	err := conn.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return uint(version), dirty, nil
+}
+
+// writeVersion stores version like the migrate CLI: a single row, and no
+// row at all for version 0.
+func writeVersion(ctx context.Context, tx *sql.Tx, version uint) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations`); err != nil {
+		return err
+	}
+	if version == 0 {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, dirty) VALUES ($1, FALSE)`, int64(version))
+	return err
+}
+
+func advisoryLock(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockId)
+	return err
+}
+
+func advisoryUnlock(conn *sql.Conn) {
+	conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, lockId)
+}
@@ -0,0 +1,192 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/hoaibao/book-management/pkg/database"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"010_books.up.sql":     {Data: []byte("CREATE TABLE book ();")},
+		"010_books.down.sql":   {Data: []byte("DROP TABLE book;")},
+		"002_authors.up.sql":   {Data: []byte("CREATE TABLE author ();")},
+		"002_authors.down.sql": {Data: []byte("DROP TABLE author;")},
+		"README.md":            {Data: []byte("not a migration")},
+		"notes.sql":            {Data: []byte("not a migration")},
+	}
+	migrations, err := load(fsys)
+	if err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+	want := []Migration{
+		{Version: 2, Name: "authors", Up: "CREATE TABLE author ();", Down: "DROP TABLE author;"},
+		{Version: 10, Name: "books", Up: "CREATE TABLE book ();", Down: "DROP TABLE book;"},
+	}
+	if len(migrations) != len(want) {
+		t.Fatalf("load() = %+v, want %+v", migrations, want)
+	}
+	for i := range want {
+		if migrations[i] != want[i] {
+			t.Errorf("migration %d = %+v, want %+v", i, migrations[i], want[i])
+		}
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{name: "missing down file", fsys: fstest.MapFS{"001_books.up.sql": {}}},
+		{name: "version out of range", fsys: fstest.MapFS{"99999999999_books.up.sql": {}, "99999999999_books.down.sql": {}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := load(test.fsys); err == nil {
+				t.Error("load() succeeded")
+			}
+		})
+	}
+}
+
+func TestLoadEmbedded(t *testing.T) {
+	migrations, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	for i, migration := range migrations {
+		if migration.Version != uint(i+1) {
+			t.Errorf("migration %d has version %d, want versions without gaps", i, migration.Version)
+		}
+		if migration.Up == "" || migration.Down == "" {
+			t.Errorf("migration %d has an empty script", migration.Version)
+		}
+	}
+}
+
+func TestMigratorVersions(t *testing.T) {
+	m := &Migrator{migrations: []Migration{{Version: 1}, {Version: 2}, {Version: 4}}}
+	if latest := m.latest(); latest != 4 {
+		t.Errorf("latest() = %d, want 4", latest)
+	}
+	tests := []struct {
+		version  uint
+		index    int
+		next     uint
+		previous uint
+	}{
+		{version: 0, index: -1, next: 1, previous: 0},
+		{version: 1, index: 0, next: 2, previous: 0},
+		{version: 2, index: 1, next: 4, previous: 1},
+		{version: 3, index: -1, next: 4, previous: 2},
+		{version: 4, index: 2, next: 4, previous: 2},
+	}
+	for _, test := range tests {
+		if index := m.index(test.version); index != test.index {
+			t.Errorf("index(%d) = %d, want %d", test.version, index, test.index)
+		}
+		if next := m.next(test.version); next != test.next {
+			t.Errorf("next(%d) = %d, want %d", test.version, next, test.next)
+		}
+		if previous := m.previous(test.version); previous != test.previous {
+			t.Errorf("previous(%d) = %d, want %d", test.version, previous, test.previous)
+		}
+	}
+}
+
+func newSQLiteMigrator(t *testing.T) *Migrator {
+	t.Helper()
+	db, err := database.NewSQLiteConnection(filepath.Join(t.TempDir(), "books.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	migrator, err := NewSQLiteMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return migrator
+}
+
+func assertVersion(t *testing.T, migrator *Migrator, want uint) {
+	t.Helper()
+	version, dirty, err := migrator.Version(context.Background())
+	if err != nil || version != want || dirty {
+		t.Fatalf("Version() = %d, %t, %v, want %d", version, dirty, err, want)
+	}
+}
+
+func TestSQLiteMigrator(t *testing.T) {
+	ctx := context.Background()
+	migrator := newSQLiteMigrator(t)
+	latest := migrator.latest()
+
+	if err := migrator.Check(ctx); !errors.Is(err, ErrVersionMismatch) {
+		t.Errorf("Check() before migrating error = %v, want %v", err, ErrVersionMismatch)
+	}
+
+	if err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	assertVersion(t, migrator, latest)
+	if err := migrator.Check(ctx); err != nil {
+		t.Errorf("Check() error = %v", err)
+	}
+	if err := migrator.Up(ctx); !errors.Is(err, ErrNoChange) {
+		t.Errorf("Up() when up to date error = %v, want %v", err, ErrNoChange)
+	}
+
+	if err := migrator.Down(ctx); err != nil {
+		t.Fatalf("Down() error = %v", err)
+	}
+	assertVersion(t, migrator, migrator.previous(latest))
+	statuses, _, _, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	for _, status := range statuses {
+		if status.Applied != (status.Version < latest) {
+			t.Errorf("Status() of version %d: applied %t", status.Version, status.Applied)
+		}
+	}
+
+	if err := migrator.Goto(ctx, 0); err != nil {
+		t.Fatalf("Goto(0) error = %v", err)
+	}
+	assertVersion(t, migrator, 0)
+	if err := migrator.Goto(ctx, latest+1); !errors.Is(err, ErrUnknownVersion) {
+		t.Errorf("Goto() an unknown version error = %v, want %v", err, ErrUnknownVersion)
+	}
+
+	// Force records a version without running its migrations.
+	if err := migrator.Force(ctx, latest); err != nil {
+		t.Fatalf("Force() error = %v", err)
+	}
+	assertVersion(t, migrator, latest)
+	if err := migrator.Force(ctx, 0); err != nil {
+		t.Fatalf("Force(0) error = %v", err)
+	}
+	assertVersion(t, migrator, 0)
+}
+
+func TestSQLiteVersionOnlyReads(t *testing.T) {
+	ctx := context.Background()
+	migrator := newSQLiteMigrator(t)
+	assertVersion(t, migrator, 0)
+	if err := migrator.Check(ctx); !errors.Is(err, ErrVersionMismatch) {
+		t.Errorf("Check() error = %v, want %v", err, ErrVersionMismatch)
+	}
+
+	var tables int
+	if err := migrator.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master`).Scan(&tables); err != nil {
+		t.Fatal(err)
+	}
+	if tables != 0 {
+		t.Errorf("reading the version created %d tables, want none", tables)
+	}
+}
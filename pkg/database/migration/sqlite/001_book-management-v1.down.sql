@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS book_revision;
+DROP TABLE IF EXISTS book_author;
+DROP TABLE IF EXISTS book;
+DROP TABLE IF EXISTS author;
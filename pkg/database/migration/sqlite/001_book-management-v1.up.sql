@@ -0,0 +1,37 @@
+CREATE TABLE author (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    "name" VARCHAR(200) NOT NULL,
+    birth_day DATE
+);
+
+CREATE TABLE book (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    isbn VARCHAR(100) NOT NULL,
+    "name" VARCHAR(200) NOT NULL,
+    publish_year SMALLINT NOT NULL,
+    deleted_at TIMESTAMP,
+    version INTEGER NOT NULL DEFAULT 1
+);
+
+CREATE UNIQUE INDEX book_isbn_key ON book (isbn) WHERE deleted_at IS NULL;
+
+CREATE INDEX book_deleted_at_idx ON book (deleted_at) WHERE deleted_at IS NOT NULL;
+
+CREATE TABLE book_author (
+    book_id INTEGER REFERENCES book(id),
+    author_id INTEGER REFERENCES author(id)
+);
+
+CREATE INDEX book_author_book_id_idx ON book_author (book_id);
+
+CREATE INDEX book_author_author_id_idx ON book_author (author_id);
+
+CREATE TABLE book_revision (
+    book_id INTEGER NOT NULL REFERENCES book(id),
+    revision INTEGER NOT NULL,
+    "action" VARCHAR(20) NOT NULL,
+    actor VARCHAR(200) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    snapshot TEXT NOT NULL,
+    PRIMARY KEY (book_id, revision)
+);
@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS book_search_author;
+DROP TRIGGER IF EXISTS book_search_unlink;
+DROP TRIGGER IF EXISTS book_search_link;
+DROP TRIGGER IF EXISTS book_search_delete;
+DROP TRIGGER IF EXISTS book_search_update;
+DROP TRIGGER IF EXISTS book_search_insert;
+DROP TABLE IF EXISTS book_search;
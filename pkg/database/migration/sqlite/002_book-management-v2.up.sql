@@ -0,0 +1,54 @@
+-- Full-text index of book names and author names, the SQLite counterpart of
+-- book.search_vector. Its rowid is the book id.
+CREATE VIRTUAL TABLE book_search USING fts5("name", authors, tokenize = 'porter unicode61');
+
+CREATE TRIGGER book_search_insert AFTER INSERT ON book
+BEGIN
+    INSERT INTO book_search (rowid, "name", authors) VALUES (NEW.id, NEW."name", '');
+END;
+
+CREATE TRIGGER book_search_update AFTER UPDATE OF "name" ON book
+BEGIN
+    UPDATE book_search SET "name" = NEW."name" WHERE rowid = NEW.id;
+END;
+
+CREATE TRIGGER book_search_delete AFTER DELETE ON book
+BEGIN
+    DELETE FROM book_search WHERE rowid = OLD.id;
+END;
+
+CREATE TRIGGER book_search_link AFTER INSERT ON book_author
+BEGIN
+    UPDATE book_search
+    SET authors = (
+        SELECT COALESCE(group_concat(a."name", ' '), '')
+        FROM book_author ba
+        JOIN author a ON ba.author_id = a.id
+        WHERE ba.book_id = NEW.book_id
+    )
+    WHERE rowid = NEW.book_id;
+END;
+
+CREATE TRIGGER book_search_unlink AFTER DELETE ON book_author
+BEGIN
+    UPDATE book_search
+    SET authors = (
+        SELECT COALESCE(group_concat(a."name", ' '), '')
+        FROM book_author ba
+        JOIN author a ON ba.author_id = a.id
+        WHERE ba.book_id = OLD.book_id
+    )
+    WHERE rowid = OLD.book_id;
+END;
+
+CREATE TRIGGER book_search_author AFTER UPDATE OF "name" ON author
+BEGIN
+    UPDATE book_search
+    SET authors = (
+        SELECT COALESCE(group_concat(a."name", ' '), '')
+        FROM book_author ba
+        JOIN author a ON ba.author_id = a.id
+        WHERE ba.book_id = book_search.rowid
+    )
+    WHERE rowid IN (SELECT book_id FROM book_author WHERE author_id = NEW.id);
+END;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS author_name_key;
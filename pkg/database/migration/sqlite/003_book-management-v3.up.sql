@@ -0,0 +1,21 @@
+-- Merge authors sharing a name into the oldest one, keeping every book.
+INSERT INTO book_author (book_id, author_id)
+SELECT DISTINCT ba.book_id, kept.id
+FROM book_author ba
+JOIN author a ON a.id = ba.author_id
+JOIN author kept ON kept.id = (SELECT MIN(id) FROM author WHERE "name" = a."name")
+WHERE a.id <> kept.id
+    AND NOT EXISTS (
+        SELECT 1 FROM book_author k WHERE k.book_id = ba.book_id AND k.author_id = kept.id
+    );
+
+DELETE FROM book_author
+WHERE author_id IN (
+    SELECT a.id FROM author a
+    WHERE a.id <> (SELECT MIN(id) FROM author WHERE "name" = a."name")
+);
+
+DELETE FROM author
+WHERE id <> (SELECT MIN(id) FROM author a WHERE a."name" = author."name");
+
+CREATE UNIQUE INDEX author_name_key ON author ("name");
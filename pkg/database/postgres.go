@@ -1,9 +1,10 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"time"
 
 	_ "github.com/lib/pq"
 )
@@ -15,9 +16,33 @@ type Config struct {
 	User     string
 	DBName   string
 	SSLMode  string
+
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// ConnectTimeout bounds the time spent waiting for the database to
+	// answer, retries included.
+	ConnectTimeout time.Duration
+	// ConnectBackoff is the wait after the first failed attempt. It doubles
+	// after each attempt, up to ConnectMaxBackoff.
+	ConnectBackoff    time.Duration
+	ConnectMaxBackoff time.Duration
 }
 
-func NewConnection(config *Config) (*sql.DB, error) {
+// ConfigurePool applies the pool settings of config to db.
+func ConfigurePool(db *sql.DB, config *Config) {
+	db.SetMaxOpenConns(config.MaxOpenConns)
+	db.SetMaxIdleConns(config.MaxIdleConns)
+	db.SetConnMaxLifetime(config.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(config.ConnMaxIdleTime)
+}
+
+// NewConnection opens a pool of connections to the PostgreSQL database of
+// config, and waits for the database to answer, so the server can start
+// along with a database that is still booting.
+func NewConnection(ctx context.Context, config *Config) (*sql.DB, error) {
 	dataSource := fmt.Sprintf(
 		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
 		config.Host,
@@ -29,15 +54,44 @@ func NewConnection(config *Config) (*sql.DB, error) {
 	)
 	db, err := sql.Open("postgres", dataSource)
 	if err != nil {
-		log.Fatal("Can't connect database", err)
+		return nil, fmt.Errorf("can't connect database: %w", err)
 	}
-	// defer db.Close()
+	ConfigurePool(db, config)
 
-	err = db.Ping()
-	if err != nil {
-		log.Fatal("Can't connect database", err)
+	if err := waitForDatabase(ctx, db, config); err != nil {
+		db.Close()
+		return nil, err
 	}
 
 	fmt.Println("Connect database successfully")
 	return db, nil
 }
+
+// waitForDatabase pings db until it answers, backing off exponentially
+// between attempts, and gives up once ctx is done or the connect timeout
+// of config expires.
+func waitForDatabase(ctx context.Context, db *sql.DB, config *Config) error {
+	ctx, cancel := context.WithTimeout(ctx, config.ConnectTimeout)
+	defer cancel()
+
+	backoff := config.ConnectBackoff
+	for attempt := 1; ; attempt++ {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("can't connect database after %d attempts in %s: %w", attempt, config.ConnectTimeout, err)
+		}
+
+		fmt.Printf("Can't connect database (attempt %d), retrying in %s: %v\n", attempt, backoff, err)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("can't connect database after %d attempts in %s: %w", attempt, config.ConnectTimeout, err)
+		case <-timer.C:
+		}
+		backoff = min(2*backoff, config.ConnectMaxBackoff)
+	}
+}
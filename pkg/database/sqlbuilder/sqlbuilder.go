@@ -0,0 +1,490 @@
+// Package sqlbuilder builds SELECT, INSERT, UPDATE and DELETE statements whose
+// values are always sent as placeholders. Every column that appears in a
+// statement must be part of the whitelist the builder was created from, so
+// user input can never reach the SQL text.
+package sqlbuilder
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrUnknownColumn = errors.New("sqlbuilder: unknown column")
+	ErrNoValues      = errors.New("sqlbuilder: no values")
+)
+
+type Whitelist map[string]struct{}
+
+func NewWhitelist(columns ...string) Whitelist {
+	whitelist := make(Whitelist, len(columns))
+	for _, column := range columns {
+		whitelist[column] = struct{}{}
+	}
+	return whitelist
+}
+
+// Merge returns a whitelist of the columns of w and others, for the
+// statements that join their tables.
+func (w Whitelist) Merge(others ...Whitelist) Whitelist {
+	merged := make(Whitelist, len(w))
+	for _, whitelist := range append([]Whitelist{w}, others...) {
+		for column := range whitelist {
+			merged[column] = struct{}{}
+		}
+	}
+	return merged
+}
+
+func (w Whitelist) Has(column string) bool {
+	_, exist := w[column]
+	return exist
+}
+
+func (w Whitelist) check(columns ...string) error {
+	for _, column := range columns {
+		if !w.Has(column) {
+			return fmt.Errorf("%w %q", ErrUnknownColumn, column)
+		}
+	}
+	return nil
+}
+
+// buffer accumulates SQL text and the arguments bound to its placeholders.
+type buffer struct {
+	sql  strings.Builder
+	args []interface{}
+}
+
+func (b *buffer) write(parts ...string) {
+	for _, part := range parts {
+		b.sql.WriteString(part)
+	}
+}
+
+func (b *buffer) bind(value interface{}) {
+	b.args = append(b.args, value)
+	b.sql.WriteString("$" + strconv.Itoa(len(b.args)))
+}
+
+// Condition is a boolean expression usable in a WHERE clause.
+type Condition interface {
+	appendTo(b *buffer, w Whitelist) error
+}
+
+type comparison struct {
+	column   string
+	operator string
+	value    interface{}
+}
+
+func (c comparison) appendTo(b *buffer, w Whitelist) error {
+	if err := w.check(c.column); err != nil {
+		return err
+	}
+	b.write(c.column, " ", c.operator, " ")
+	b.bind(c.value)
+	return nil
+}
+
+func Eq(column string, value interface{}) Condition {
+	return comparison{column: column, operator: "=", value: value}
+}
+
+func Gte(column string, value interface{}) Condition {
+	return comparison{column: column, operator: ">=", value: value}
+}
+
+func Lte(column string, value interface{}) Condition {
+	return comparison{column: column, operator: "<=", value: value}
+}
+
+type in struct {
+	column string
+	values []interface{}
+}
+
+func (c in) appendTo(b *buffer, w Whitelist) error {
+	if err := w.check(c.column); err != nil {
+		return err
+	}
+	if len(c.values) == 0 {
+		b.write("FALSE")
+		return nil
+	}
+	b.write(c.column, " IN (")
+	for i, value := range c.values {
+		if i > 0 {
+			b.write(", ")
+		}
+		b.bind(value)
+	}
+	b.write(")")
+	return nil
+}
+
+func In(column string, values ...interface{}) Condition {
+	return in{column: column, values: values}
+}
+
+// Expression is trusted SQL written by the caller in which each "?" is
+// replaced by a placeholder bound to the matching argument.
+type Expression struct {
+	sql  string
+	args []interface{}
+}
+
+func Expr(sql string, args ...interface{}) Expression {
+	return Expression{sql: sql, args: args}
+}
+
+func (e Expression) appendTo(b *buffer, _ Whitelist) error {
+	parts := strings.Split(e.sql, "?")
+	if len(parts)-1 != len(e.args) {
+		return fmt.Errorf("sqlbuilder: expression %q has %d placeholders, got %d arguments", e.sql, len(parts)-1, len(e.args))
+	}
+	for i, part := range parts {
+		if i > 0 {
+			b.bind(e.args[i-1])
+		}
+		b.write(part)
+	}
+	return nil
+}
+
+type inSelect struct {
+	column string
+	query  *SelectBuilder
+}
+
+func (c inSelect) appendTo(b *buffer, w Whitelist) error {
+	if err := w.check(c.column); err != nil {
+		return err
+	}
+	b.write(c.column, " IN (")
+	if err := c.query.appendTo(b); err != nil {
+		return err
+	}
+	b.write(")")
+	return nil
+}
+
+// InSelect matches rows whose column is returned by the sub query. The sub
+// query shares the placeholders of the outer statement.
+func InSelect(column string, query *SelectBuilder) Condition {
+	return inSelect{column: column, query: query}
+}
+
+func writeWhere(b *buffer, w Whitelist, conditions []Condition) error {
+	for i, condition := range conditions {
+		if i == 0 {
+			b.write(" WHERE ")
+		} else {
+			b.write(" AND ")
+		}
+		if err := condition.appendTo(b, w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeReturning(b *buffer, columns []string) {
+	if len(columns) > 0 {
+		b.write(" RETURNING ", strings.Join(columns, ", "))
+	}
+}
+
+type SelectBuilder struct {
+	whitelist  Whitelist
+	columns    []selectTerm
+	from       string
+	joins      []string
+	conditions []Condition
+	orderBy    []orderTerm
+	limit      int
+	offset     int
+}
+
+type selectTerm struct {
+	column     string
+	expression *Expression
+}
+
+type orderTerm struct {
+	column     string
+	expression *Expression
+	desc       bool
+}
+
+// Select starts a SELECT statement. Table and join clauses are trusted SQL
+// written by the caller; columns and conditions are checked.
+func (w Whitelist) Select(columns ...string) *SelectBuilder {
+	s := &SelectBuilder{whitelist: w}
+	for _, column := range columns {
+		s.columns = append(s.columns, selectTerm{column: column})
+	}
+	return s
+}
+
+// SelectExpr adds a trusted expression to the selected columns, for
+// aggregates and sub queries.
+func (s *SelectBuilder) SelectExpr(expression Expression) *SelectBuilder {
+	s.columns = append(s.columns, selectTerm{expression: &expression})
+	return s
+}
+
+func (s *SelectBuilder) From(table string) *SelectBuilder {
+	s.from = table
+	return s
+}
+
+func (s *SelectBuilder) Join(clause string) *SelectBuilder {
+	s.joins = append(s.joins, clause)
+	return s
+}
+
+func (s *SelectBuilder) Where(conditions ...Condition) *SelectBuilder {
+	s.conditions = append(s.conditions, conditions...)
+	return s
+}
+
+// OrderBy sorts by the given columns. A column prefixed with "-" is sorted
+// in descending order.
+func (s *SelectBuilder) OrderBy(columns ...string) *SelectBuilder {
+	for _, column := range columns {
+		s.orderBy = append(s.orderBy, orderTerm{
+			column: strings.TrimPrefix(column, "-"),
+			desc:   strings.HasPrefix(column, "-"),
+		})
+	}
+	return s
+}
+
+// OrderByExpr sorts by a trusted expression, for values that need arguments.
+func (s *SelectBuilder) OrderByExpr(expression Expression, desc bool) *SelectBuilder {
+	s.orderBy = append(s.orderBy, orderTerm{expression: &expression, desc: desc})
+	return s
+}
+
+// Limit caps the number of rows returned. Zero means no limit.
+func (s *SelectBuilder) Limit(limit int) *SelectBuilder {
+	s.limit = limit
+	return s
+}
+
+func (s *SelectBuilder) Offset(offset int) *SelectBuilder {
+	s.offset = offset
+	return s
+}
+
+func (s *SelectBuilder) Build() (string, []interface{}, error) {
+	var b buffer
+	if err := s.appendTo(&b); err != nil {
+		return "", nil, err
+	}
+	return b.sql.String(), b.args, nil
+}
+
+func (s *SelectBuilder) appendTo(b *buffer) error {
+	for _, term := range s.columns {
+		if term.expression == nil {
+			if err := s.whitelist.check(term.column); err != nil {
+				return err
+			}
+		}
+	}
+	for _, term := range s.orderBy {
+		if term.expression == nil {
+			if err := s.whitelist.check(term.column); err != nil {
+				return err
+			}
+		}
+	}
+
+	b.write("SELECT ")
+	for i, term := range s.columns {
+		if i > 0 {
+			b.write(", ")
+		}
+		if term.expression != nil {
+			if err := term.expression.appendTo(b, s.whitelist); err != nil {
+				return err
+			}
+		} else {
+			b.write(term.column)
+		}
+	}
+	b.write(" FROM ", s.from)
+	for _, join := range s.joins {
+		b.write(" JOIN ", join)
+	}
+	if err := writeWhere(b, s.whitelist, s.conditions); err != nil {
+		return err
+	}
+	for i, term := range s.orderBy {
+		if i == 0 {
+			b.write(" ORDER BY ")
+		} else {
+			b.write(", ")
+		}
+		if term.expression != nil {
+			if err := term.expression.appendTo(b, s.whitelist); err != nil {
+				return err
+			}
+		} else {
+			b.write(term.column)
+		}
+		if term.desc {
+			b.write(" DESC")
+		} else {
+			b.write(" ASC")
+		}
+	}
+	if s.limit > 0 {
+		b.write(" LIMIT ")
+		b.bind(s.limit)
+	}
+	if s.offset > 0 {
+		b.write(" OFFSET ")
+		b.bind(s.offset)
+	}
+	return nil
+}
+
+type InsertBuilder struct {
+	whitelist Whitelist
+	table     string
+	columns   []string
+	rows      [][]interface{}
+	returning []string
+}
+
+func (w Whitelist) Insert(table string, columns ...string) *InsertBuilder {
+	return &InsertBuilder{whitelist: w, table: table, columns: columns}
+}
+
+func (i *InsertBuilder) Values(values ...interface{}) *InsertBuilder {
+	i.rows = append(i.rows, values)
+	return i
+}
+
+func (i *InsertBuilder) Returning(columns ...string) *InsertBuilder {
+	i.returning = columns
+	return i
+}
+
+func (i *InsertBuilder) Build() (string, []interface{}, error) {
+	if err := i.whitelist.check(i.columns...); err != nil {
+		return "", nil, err
+	}
+	if err := i.whitelist.check(i.returning...); err != nil {
+		return "", nil, err
+	}
+	if len(i.rows) == 0 {
+		return "", nil, ErrNoValues
+	}
+
+	var b buffer
+	b.write("INSERT INTO ", i.table, "(", strings.Join(i.columns, ", "), ") VALUES ")
+	for rowIndex, row := range i.rows {
+		if len(row) != len(i.columns) {
+			return "", nil, fmt.Errorf("sqlbuilder: row %d has %d values, want %d", rowIndex, len(row), len(i.columns))
+		}
+		if rowIndex > 0 {
+			b.write(", ")
+		}
+		b.write("(")
+		for valueIndex, value := range row {
+			if valueIndex > 0 {
+				b.write(", ")
+			}
+			b.bind(value)
+		}
+		b.write(")")
+	}
+	writeReturning(&b, i.returning)
+	return b.sql.String(), b.args, nil
+}
+
+type UpdateBuilder struct {
+	whitelist  Whitelist
+	table      string
+	columns    []string
+	values     []interface{}
+	conditions []Condition
+	returning  []string
+}
+
+func (w Whitelist) Update(table string) *UpdateBuilder {
+	return &UpdateBuilder{whitelist: w, table: table}
+}
+
+func (u *UpdateBuilder) Set(column string, value interface{}) *UpdateBuilder {
+	u.columns = append(u.columns, column)
+	u.values = append(u.values, value)
+	return u
+}
+
+func (u *UpdateBuilder) Where(conditions ...Condition) *UpdateBuilder {
+	u.conditions = append(u.conditions, conditions...)
+	return u
+}
+
+func (u *UpdateBuilder) Returning(columns ...string) *UpdateBuilder {
+	u.returning = columns
+	return u
+}
+
+func (u *UpdateBuilder) Build() (string, []interface{}, error) {
+	if err := u.whitelist.check(u.columns...); err != nil {
+		return "", nil, err
+	}
+	if err := u.whitelist.check(u.returning...); err != nil {
+		return "", nil, err
+	}
+	if len(u.columns) == 0 {
+		return "", nil, ErrNoValues
+	}
+
+	var b buffer
+	b.write("UPDATE ", u.table, " SET ")
+	for i, column := range u.columns {
+		if i > 0 {
+			b.write(", ")
+		}
+		b.write(column, " = ")
+		b.bind(u.values[i])
+	}
+	if err := writeWhere(&b, u.whitelist, u.conditions); err != nil {
+		return "", nil, err
+	}
+	writeReturning(&b, u.returning)
+	return b.sql.String(), b.args, nil
+}
+
+type DeleteBuilder struct {
+	whitelist  Whitelist
+	table      string
+	conditions []Condition
+}
+
+func (w Whitelist) Delete(table string) *DeleteBuilder {
+	return &DeleteBuilder{whitelist: w, table: table}
+}
+
+func (d *DeleteBuilder) Where(conditions ...Condition) *DeleteBuilder {
+	d.conditions = append(d.conditions, conditions...)
+	return d
+}
+
+func (d *DeleteBuilder) Build() (string, []interface{}, error) {
+	var b buffer
+	b.write("DELETE FROM ", d.table)
+	if err := writeWhere(&b, d.whitelist, d.conditions); err != nil {
+		return "", nil, err
+	}
+	return b.sql.String(), b.args, nil
+}
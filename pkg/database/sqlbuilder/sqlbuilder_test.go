@@ -0,0 +1,148 @@
+package sqlbuilder
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type builder interface {
+	Build() (string, []interface{}, error)
+}
+
+var (
+	books   = NewWhitelist("id", "name", "isbn", "b.id", "b.name")
+	authors = NewWhitelist("book_id", "author_id")
+)
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		name     string
+		builder  builder
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{
+			name:     "select",
+			builder:  books.Select("id", "name").From("book").Where(Eq("isbn", "978"), Gte("id", 2), Lte("id", 5)),
+			wantSQL:  "SELECT id, name FROM book WHERE isbn = $1 AND id >= $2 AND id <= $3",
+			wantArgs: []interface{}{"978", 2, 5},
+		},
+		{
+			name:     "select with order, limit and offset",
+			builder:  books.Select("b.id").From("book b").OrderBy("-b.name", "b.id").Limit(10).Offset(20),
+			wantSQL:  "SELECT b.id FROM book b ORDER BY b.name DESC, b.id ASC LIMIT $1 OFFSET $2",
+			wantArgs: []interface{}{10, 20},
+		},
+		{
+			name:     "select expression",
+			builder:  books.Select().SelectExpr(Expr("COUNT(*)")).From("book").Where(Eq("name", "Go")),
+			wantSQL:  "SELECT COUNT(*) FROM book WHERE name = $1",
+			wantArgs: []interface{}{"Go"},
+		},
+		{
+			name:     "expressions share the placeholders",
+			builder:  books.Select("id").SelectExpr(Expr("rank(?)", "q")).From("book").Where(Expr("name LIKE ?", "G%")).OrderByExpr(Expr("rank(?)", "q"), true),
+			wantSQL:  "SELECT id, rank($1) FROM book WHERE name LIKE $2 ORDER BY rank($3) DESC",
+			wantArgs: []interface{}{"q", "G%", "q"},
+		},
+		{
+			name:     "in",
+			builder:  books.Select("id").From("book").Where(In("id", 1, 2, 3)),
+			wantSQL:  "SELECT id FROM book WHERE id IN ($1, $2, $3)",
+			wantArgs: []interface{}{1, 2, 3},
+		},
+		{
+			name:    "in without values",
+			builder: books.Select("id").From("book").Where(In("id")),
+			wantSQL: "SELECT id FROM book WHERE FALSE",
+		},
+		{
+			name:     "in select",
+			builder:  books.Delete("book").Where(Eq("name", "Go"), InSelect("id", authors.Select("book_id").From("book_author").Where(Eq("author_id", 7)))),
+			wantSQL:  "DELETE FROM book WHERE name = $1 AND id IN (SELECT book_id FROM book_author WHERE author_id = $2)",
+			wantArgs: []interface{}{"Go", 7},
+		},
+		{
+			name:     "insert",
+			builder:  books.Insert("book", "name", "isbn").Values("Go", "978").Values("C", "979").Returning("id"),
+			wantSQL:  "INSERT INTO book(name, isbn) VALUES ($1, $2), ($3, $4) RETURNING id",
+			wantArgs: []interface{}{"Go", "978", "C", "979"},
+		},
+		{
+			name:     "update",
+			builder:  books.Update("book").Set("name", "Go").Set("isbn", "978").Where(Eq("id", 1)),
+			wantSQL:  "UPDATE book SET name = $1, isbn = $2 WHERE id = $3",
+			wantArgs: []interface{}{"Go", "978", 1},
+		},
+		{
+			name:    "merged whitelists",
+			builder: books.Merge(authors).Select("b.id", "author_id").From("book b").Join("book_author ON b.id = book_id"),
+			wantSQL: "SELECT b.id, author_id FROM book b JOIN book_author ON b.id = book_id",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sql, args, err := test.builder.Build()
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+			if sql != test.wantSQL {
+				t.Errorf("Build() sql = %q, want %q", sql, test.wantSQL)
+			}
+			if !reflect.DeepEqual(args, test.wantArgs) {
+				t.Errorf("Build() args = %v, want %v", args, test.wantArgs)
+			}
+		})
+	}
+}
+
+func TestBuildRejectsUnknownColumns(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder builder
+	}{
+		{name: "select column", builder: books.Select("password").From("book")},
+		{name: "select injection", builder: books.Select("id; DROP TABLE book").From("book")},
+		{name: "where column", builder: books.Select("id").From("book").Where(Eq("password", "x"))},
+		{name: "in column", builder: books.Select("id").From("book").Where(In("password", 1))},
+		{name: "order column", builder: books.Select("id").From("book").OrderBy("-password")},
+		{name: "column of another table", builder: books.Select("author_id").From("book")},
+		{name: "sub query column", builder: books.Select("id").From("book").Where(InSelect("id", authors.Select("name").From("book_author")))},
+		{name: "insert column", builder: books.Insert("book", "password").Values("x")},
+		{name: "insert returning", builder: books.Insert("book", "name").Values("Go").Returning("password")},
+		{name: "update column", builder: books.Update("book").Set("password", "x")},
+		{name: "delete condition", builder: books.Delete("book").Where(Eq("password", "x"))},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, _, err := test.builder.Build(); !errors.Is(err, ErrUnknownColumn) {
+				t.Errorf("Build() error = %v, want %v", err, ErrUnknownColumn)
+			}
+		})
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder builder
+		err     error
+	}{
+		{name: "insert without rows", builder: books.Insert("book", "name"), err: ErrNoValues},
+		{name: "update without columns", builder: books.Update("book").Where(Eq("id", 1)), err: ErrNoValues},
+		{name: "row of the wrong size", builder: books.Insert("book", "name", "isbn").Values("Go")},
+		{name: "expression missing an argument", builder: books.Select("id").From("book").Where(Expr("name = ?"))},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, err := test.builder.Build()
+			if err == nil {
+				t.Fatal("Build() succeeded")
+			}
+			if test.err != nil && !errors.Is(err, test.err) {
+				t.Errorf("Build() error = %v, want %v", err, test.err)
+			}
+		})
+	}
+}
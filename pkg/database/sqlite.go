@@ -0,0 +1,28 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	_ "modernc.org/sqlite"
+)
+
+// NewSQLiteConnection opens the SQLite database file at path, creating it
+// if needed. Foreign keys are enforced like in PostgreSQL, and write
+// transactions take the database lock up front and wait for it rather than
+// failing when another connection holds it.
+func NewSQLiteConnection(path string) (*sql.DB, error) {
+	dataSource := fmt.Sprintf(
+		"file:%s?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_txlock=immediate",
+		path,
+	)
+	db, err := sql.Open("sqlite", dataSource)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
@@ -3,5 +3,5 @@ package domain
 type Author struct {
 	Id       int    `json:"id"`
 	Name     string `json:"name"`
-	BirthDay string `json:"birthDay"`
+	BirthDay Date   `json:"birthDay"`
 }
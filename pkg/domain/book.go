@@ -1,9 +1,14 @@
 package domain
 
+import "time"
+
+// Book.Version starts at 1 and is bumped by every change of the book.
 type Book struct {
-	Id          int      `json:"id"`
-	Name        string   `json:"name"`
-	ISBN        string   `json:"isbn"`
-	PublishYear int      `json:"publishYear"`
-	Authors     []Author `json:"authors"`
+	Id          int        `json:"id"`
+	Name        string     `json:"name"`
+	ISBN        string     `json:"isbn"`
+	PublishYear int        `json:"publishYear"`
+	Authors     []Author   `json:"authors"`
+	DeletedAt   *time.Time `json:"deletedAt,omitempty"`
+	Version     int        `json:"version"`
 }
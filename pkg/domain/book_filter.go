@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	SortById          = "id"
+	SortByName        = "name"
+	SortByISBN        = "isbn"
+	SortByPublishYear = "publishYear"
+	SortByAuthor      = "author"
+)
+
+// SortField orders books by one of the SortBy keys. Books are sorted by
+// author using the first of their author names in alphabetical order.
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// BookFilter selects a page of books. From and To are publish years and only
+// apply when both are set. Query is a keyword search over book names and
+// author names. Limit is the page size: the book service sets a zero Limit
+// to 20 and refuses more than 100, so only the repositories, called
+// directly, see a zero Limit and return every matching book. Books are
+// ordered by Sort, or by relevance when searching without a Sort, then by id.
+type BookFilter struct {
+	ISBN   string
+	Author string
+	From   string
+	To     string
+	Query  string
+	Sort   []SortField
+	Limit  int
+	Offset int
+}
+
+type BookPage struct {
+	Books  []Book `json:"books"`
+	Total  int    `json:"total"`
+	Limit  int    `json:"limit"`
+	Offset int    `json:"offset"`
+}
+
+// SearchWords splits a search query into its words. Punctuation separates
+// words and is otherwise ignored, so a query of punctuation only has none.
+func SearchWords(query string) []string {
+	return strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// SearchTerms returns the lowercase words of a search query that are looked
+// up, leaving out stopWords like PostgreSQL's english configuration does. A
+// query of stop words only has no terms and matches no book.
+func SearchTerms(query string) []string {
+	words := SearchWords(strings.ToLower(query))
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		if !stopWords[word] {
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+// stopWords is the english stop word list of PostgreSQL full-text search.
+var stopWords = wordSet(`
+	i me my myself we our ours ourselves you your yours yourself yourselves
+	he him his himself she her hers herself it its itself they them their
+	theirs themselves what which who whom this that these those am is are
+	was were be been being have has had having do does did doing a an the
+	and but if or because as until while of at by for with about against
+	between into through during before after above below to from up down
+	in out on off over under again further then once here there when where
+	why how all any both each few more most other some such no nor not only
+	own same so than too very s t can will just don should now`)
+
+func wordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
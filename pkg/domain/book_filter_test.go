@@ -0,0 +1,24 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSearchTerms(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{query: "Programming Language", want: []string{"programming", "language"}},
+		{query: "The C Programming Language", want: []string{"c", "programming", "language"}},
+		{query: `"go" OR c*`, want: []string{"go", "c"}},
+		{query: "the and of", want: []string{}},
+		{query: "*", want: []string{}},
+	}
+	for _, test := range tests {
+		if got := SearchTerms(test.query); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("SearchTerms(%q) = %q, want %q", test.query, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// DateLayout is the ISO-8601 calendar date format used for birthdays.
+const DateLayout = "2006-01-02"
+
+var ErrInvalidDate = fmt.Errorf("%w: date must be formatted as YYYY-MM-DD", ErrValidation)
+
+// Date is a calendar date without time of day that may be unknown. The zero
+// value is the unknown date, stored as NULL and serialized as JSON null.
+type Date struct {
+	Time  time.Time
+	Valid bool
+}
+
+func NewDate(year int, month time.Month, day int) Date {
+	return Date{Time: time.Date(year, month, day, 0, 0, 0, 0, time.UTC), Valid: true}
+}
+
+// ParseDate parses a YYYY-MM-DD date. An empty string is the unknown date.
+func ParseDate(value string) (Date, error) {
+	if value == "" {
+		return Date{}, nil
+	}
+	t, err := time.Parse(DateLayout, value)
+	if err != nil {
+		return Date{}, ErrInvalidDate
+	}
+	return Date{Time: t, Valid: true}, nil
+}
+
+// String returns the date as YYYY-MM-DD, or an empty string when unknown.
+func (d Date) String() string {
+	if !d.Valid {
+		return ""
+	}
+	return d.Time.Format(DateLayout)
+}
+
+func (d Date) After(t time.Time) bool {
+	return d.Valid && d.Time.After(t)
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	if !d.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON accepts null, an empty string or a YYYY-MM-DD string.
+func (d *Date) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*d = Date{}
+		return nil
+	}
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return ErrInvalidDate
+	}
+	date, err := ParseDate(value)
+	if err != nil {
+		return err
+	}
+	*d = date
+	return nil
+}
+
+// Scan reads a nullable DATE column.
+func (d *Date) Scan(src interface{}) error {
+	switch value := src.(type) {
+	case nil:
+		*d = Date{}
+	case time.Time:
+		*d = NewDate(value.Year(), value.Month(), value.Day())
+	case string:
+		return d.scanText(value)
+	case []byte:
+		return d.scanText(string(value))
+	default:
+		return fmt.Errorf("cannot scan %T into Date", src)
+	}
+	return nil
+}
+
+func (d *Date) scanText(value string) error {
+	if len(value) > len(DateLayout) {
+		value = value[:len(DateLayout)]
+	}
+	date, err := ParseDate(value)
+	if err != nil {
+		return fmt.Errorf("cannot scan %q into Date", value)
+	}
+	*d = date
+	return nil
+}
+
+// Value stores the date as YYYY-MM-DD text so the database does not apply
+// its time zone to it.
+func (d Date) Value() (driver.Value, error) {
+	if !d.Valid {
+		return nil, nil
+	}
+	return d.String(), nil
+}
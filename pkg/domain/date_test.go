@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDateUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want Date
+		err  error
+	}{
+		{name: "date", json: `"1970-01-31"`, want: NewDate(1970, time.January, 31)},
+		{name: "null", json: `null`, want: Date{}},
+		{name: "empty string", json: `""`, want: Date{}},
+		{name: "number", json: `19700131`, err: ErrInvalidDate},
+		{name: "time of day", json: `"1970-01-31T10:00:00Z"`, err: ErrInvalidDate},
+		{name: "day out of range", json: `"1970-02-30"`, err: ErrInvalidDate},
+		{name: "other layout", json: `"31/01/1970"`, err: ErrInvalidDate},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			date := NewDate(2000, time.January, 1)
+			err := json.Unmarshal([]byte(test.json), &date)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("Unmarshal(%s) error = %v, want %v", test.json, err, test.err)
+			}
+			if err == nil && date != test.want {
+				t.Errorf("Unmarshal(%s) = %v, want %v", test.json, date, test.want)
+			}
+			if err != nil && !errors.Is(err, ErrValidation) {
+				t.Errorf("Unmarshal(%s) error = %v, want a validation error", test.json, err)
+			}
+		})
+	}
+}
+
+func TestDateMarshalJSON(t *testing.T) {
+	tests := []struct {
+		date Date
+		want string
+	}{
+		{date: NewDate(1970, time.January, 31), want: `"1970-01-31"`},
+		{date: Date{}, want: `null`},
+	}
+	for _, test := range tests {
+		got, err := json.Marshal(test.date)
+		if err != nil {
+			t.Fatalf("Marshal(%v) error = %v", test.date, err)
+		}
+		if string(got) != test.want {
+			t.Errorf("Marshal(%v) = %s, want %s", test.date, got, test.want)
+		}
+	}
+}
+
+func TestDateScan(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     interface{}
+		want    Date
+		wantErr bool
+	}{
+		{name: "nil", src: nil, want: Date{}},
+		{name: "time", src: time.Date(1970, time.January, 31, 23, 30, 0, 0, time.UTC), want: NewDate(1970, time.January, 31)},
+		{name: "time in another zone", src: time.Date(1970, time.January, 31, 1, 0, 0, 0, time.FixedZone("UTC+7", 7*3600)), want: NewDate(1970, time.January, 31)},
+		{name: "string", src: "1970-01-31", want: NewDate(1970, time.January, 31)},
+		{name: "string with time", src: "1970-01-31T00:00:00Z", want: NewDate(1970, time.January, 31)},
+		{name: "bytes", src: []byte("1970-01-31"), want: NewDate(1970, time.January, 31)},
+		{name: "invalid string", src: "31/01/1970", wantErr: true},
+		{name: "unsupported type", src: int64(19700131), wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var date Date
+			err := date.Scan(test.src)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Scan(%v) error = %v, want error %t", test.src, err, test.wantErr)
+			}
+			if date != test.want {
+				t.Errorf("Scan(%v) = %v, want %v", test.src, date, test.want)
+			}
+		})
+	}
+}
+
+func TestDateValue(t *testing.T) {
+	value, err := NewDate(1970, time.January, 31).Value()
+	if err != nil || value != "1970-01-31" {
+		t.Errorf("Value() = %v, %v, want 1970-01-31", value, err)
+	}
+	value, err = Date{}.Value()
+	if err != nil || value != nil {
+		t.Errorf("Value() of the unknown date = %v, %v, want nil", value, err)
+	}
+}
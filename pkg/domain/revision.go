@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+const (
+	RevisionCreate  = "create"
+	RevisionUpdate  = "update"
+	RevisionDelete  = "delete"
+	RevisionRestore = "restore"
+)
+
+// BookRevision is a snapshot of a book taken after each change. Revisions
+// of a book are numbered from 1.
+type BookRevision struct {
+	BookId    int           `json:"bookId"`
+	Revision  int           `json:"revision"`
+	Action    string        `json:"action"`
+	Actor     string        `json:"actor"`
+	CreatedAt time.Time     `json:"createdAt"`
+	Book      Book          `json:"book"`
+	Changes   []FieldChange `json:"changes"`
+}
+
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// DiffBooks lists the fields that differ between two snapshots of a book.
+// Authors are compared by name.
+func DiffBooks(from, to Book) []FieldChange {
+	changes := make([]FieldChange, 0)
+	if from.Name != to.Name {
+		changes = append(changes, FieldChange{Field: "name", From: from.Name, To: to.Name})
+	}
+	if from.ISBN != to.ISBN {
+		changes = append(changes, FieldChange{Field: "isbn", From: from.ISBN, To: to.ISBN})
+	}
+	if from.PublishYear != to.PublishYear {
+		changes = append(changes, FieldChange{Field: "publishYear", From: from.PublishYear, To: to.PublishYear})
+	}
+	if fromAuthors, toAuthors := AuthorNames(from.Authors), AuthorNames(to.Authors); !slices.Equal(fromAuthors, toAuthors) {
+		changes = append(changes, FieldChange{Field: "author", From: fromAuthors, To: toAuthors})
+	}
+	return changes
+}
+
+func AuthorNames(authors []Author) []string {
+	names := make([]string, 0, len(authors))
+	for _, author := range authors {
+		names = append(names, author.Name)
+	}
+	return names
+}
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx recording who makes the changes.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor set with WithActor, or "anonymous".
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return "anonymous"
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/hoaibao/book-management/pkg/domain"
+	"github.com/hoaibao/book-management/pkg/service"
+)
+
+type AuthorHandler struct {
+	authorService *service.AuthorService
+}
+
+type authorRequest struct {
+	Name     string      `json:"name"`
+	BirthDay domain.Date `json:"birthDay"`
+}
+
+func NewAuthorHandler(authorService *service.AuthorService) *AuthorHandler {
+	return &AuthorHandler{
+		authorService: authorService,
+	}
+}
+
+func (h *AuthorHandler) GetAllAuthorsHandler(w http.ResponseWriter, r *http.Request) {
+	authors, err := h.authorService.GetAllAuthors(r.Context())
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, authors)
+}
+
+func (h *AuthorHandler) GetAuthorByIdHandler(w http.ResponseWriter, r *http.Request) {
+	authorId, err := strconv.Atoi(mux.Vars(r)["authorId"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid author id")
+		return
+	}
+
+	author, err := h.authorService.GetAuthorById(r.Context(), authorId)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, author)
+}
+
+func (h *AuthorHandler) GetBooksByAuthorIdHandler(w http.ResponseWriter, r *http.Request) {
+	authorId, err := strconv.Atoi(mux.Vars(r)["authorId"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid author id")
+		return
+	}
+
+	books, err := h.authorService.GetBooksByAuthorId(r.Context(), authorId)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, books)
+}
+
+func (h *AuthorHandler) CreateAuthorHandler(w http.ResponseWriter, r *http.Request) {
+	authorData, ok := decodeAuthorRequest(w, r)
+	if !ok {
+		return
+	}
+
+	author, err := h.authorService.CreateAuthor(r.Context(), authorData.Name, authorData.BirthDay)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, author)
+}
+
+func (h *AuthorHandler) UpdateAuthorByIdHandler(w http.ResponseWriter, r *http.Request) {
+	authorId, err := strconv.Atoi(mux.Vars(r)["authorId"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid author id")
+		return
+	}
+
+	authorData, ok := decodeAuthorRequest(w, r)
+	if !ok {
+		return
+	}
+
+	author, err := h.authorService.UpdateAuthorById(r.Context(), authorId, authorData.Name, authorData.BirthDay)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, author)
+}
+
+func (h *AuthorHandler) DeleteAuthorByIdHandler(w http.ResponseWriter, r *http.Request) {
+	authorId, err := strconv.Atoi(mux.Vars(r)["authorId"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid author id")
+		return
+	}
+
+	author, err := h.authorService.DeleteAuthorById(r.Context(), authorId)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, author)
+}
+
+// decodeAuthorRequest reads the request body, reporting malformed birthdays
+// as validation errors of the birthDay field.
+func decodeAuthorRequest(w http.ResponseWriter, r *http.Request) (authorRequest, bool) {
+	var authorData authorRequest
+	err := json.NewDecoder(r.Body).Decode(&authorData)
+	switch {
+	case errors.Is(err, domain.ErrInvalidDate):
+		writeServiceError(w, domain.NewValidationError("birthDay", "must be a date formatted as YYYY-MM-DD or null"))
+		return authorRequest{}, false
+	case err != nil:
+		writeDecodeError(w, err)
+		return authorRequest{}, false
+	}
+	return authorData, true
+}
@@ -2,10 +2,10 @@ package handler
 
 import (
 	"encoding/json"
-	"fmt"
-	"log"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/hoaibao/book-management/pkg/domain"
@@ -22,51 +22,112 @@ func NewBookHandler(bookService *service.BookService) *BookHandler {
 	}
 }
 
+type bookListResponse struct {
+	domain.BookPage
+	Next *string `json:"next"`
+	Prev *string `json:"prev"`
+}
+
 func (h *BookHandler) GetAllBooksHandler(w http.ResponseWriter, r *http.Request) {
-	fromValue := r.URL.Query().Get("from")
-	toValue := r.URL.Query().Get("to")
-	isbnValue := r.URL.Query().Get("isbn")
-	authorValue := r.URL.Query().Get("author")
+	query := r.URL.Query()
+	filter := domain.BookFilter{
+		ISBN:   query.Get("isbn"),
+		Author: query.Get("author"),
+		From:   query.Get("from"),
+		To:     query.Get("to"),
+		Query:  strings.TrimSpace(query.Get("q")),
+	}
 
-	books, err := h.bookService.GetAllBooks(isbnValue, authorValue, fromValue, toValue)
+	var err error
+	if filter.Sort, err = service.ParseSort(query.Get("sort")); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if filter.Limit, err = intQueryParam(query, "limit"); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if filter.Offset, err = intQueryParam(query, "offset"); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if cursor := query.Get("cursor"); cursor != "" {
+		if query.Has("offset") {
+			writeServiceError(w, domain.NewValidationError("cursor", "can't be combined with offset"))
+			return
+		}
+		if filter.Offset, err = service.DecodeCursor(cursor); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+	}
 
+	page, err := h.bookService.GetAllBooks(r.Context(), filter)
 	if err != nil {
-		fmt.Println(err)
-		http.Error(w, "Error retrieving books", http.StatusInternalServerError)
+		writeServiceError(w, err)
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(books)
+
+	response := bookListResponse{BookPage: page}
+	if page.Offset+len(page.Books) < page.Total {
+		response.Next = pageLink(r, page.Limit, page.Offset+page.Limit)
+	}
+	if page.Offset > 0 {
+		response.Prev = pageLink(r, page.Limit, max(page.Offset-page.Limit, 0))
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// pageLink returns the URL of the request with its paging replaced by a
+// cursor pointing at offset.
+func pageLink(r *http.Request, limit, offset int) *string {
+	query := r.URL.Query()
+	query.Del("offset")
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("cursor", service.EncodeCursor(offset))
+	link := (&url.URL{Path: r.URL.Path, RawQuery: query.Encode()}).String()
+	return &link
+}
+
+func intQueryParam(query url.Values, name string) (int, error) {
+	value := query.Get(name)
+	if value == "" {
+		return 0, nil
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, domain.NewValidationError(name, "must be an integer")
+	}
+	return number, nil
 }
 
 func (h *BookHandler) GetBookByIdHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	bookId, err := strconv.Atoi(vars["bookId"])
 	if err != nil {
-		http.Error(w, "Invalid book id", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "Invalid book id")
 		return
 	}
 
-	book, err := h.bookService.GetBookById(bookId)
+	book, err := h.bookService.GetBookById(r.Context(), bookId)
 	if err != nil {
-		http.Error(w, "Error retrieving book", http.StatusInternalServerError)
+		writeServiceError(w, err)
+		return
 	}
-
-	// if book == (domain.Book{}) {
-	// 	http.Error(w, "Book not found", http.StatusNotFound)
-	// }
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(book)
+	etag := bookETag(book)
+	w.Header().Set("ETag", etag)
+	if notModified(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	writeJSON(w, http.StatusOK, book)
 }
 
 func (h *BookHandler) CreateBookHandler(w http.ResponseWriter, r *http.Request) {
 	var bookDataList []map[string][]string
 	var response []domain.Book
 	if err := json.NewDecoder(r.Body).Decode(&bookDataList); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeDecodeError(w, err)
 		return
 	}
 
@@ -76,116 +137,260 @@ func (h *BookHandler) CreateBookHandler(w http.ResponseWriter, r *http.Request)
 		author, authorExists := bookData["author"]
 		publishYear, publishYearExists := bookData["publishYear"]
 
-		if !nameExists || !isbnExists || !authorExists || !publishYearExists {
-			http.Error(w, "Missing required fields", http.StatusBadRequest)
+		if !nameExists || !isbnExists || !authorExists || !publishYearExists ||
+			len(name) == 0 || len(isbn) == 0 || len(publishYear) == 0 {
+			writeError(w, http.StatusBadRequest, "Missing required fields")
 			return
 		}
 
 		publishYearInt, err := strconv.Atoi(publishYear[0])
 		if err != nil {
-			http.Error(w, "Invalid publish year", http.StatusBadRequest)
+			writeServiceError(w, domain.NewValidationError("publishYear", "must be an integer"))
+			return
 		}
 
-		book, err := h.bookService.CreateBook(name[0], isbn[0], author, publishYearInt)
+		book, err := h.bookService.CreateBook(r.Context(), name[0], isbn[0], author, publishYearInt)
 		if err != nil {
-			http.Error(w, "Error creating book", http.StatusInternalServerError)
+			writeServiceError(w, err)
 			return
 		}
 		response = append(response, book)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	writeJSON(w, http.StatusOK, response)
 }
 
 func (h *BookHandler) DeleteMultipleBookByIdHandler(w http.ResponseWriter, r *http.Request) {
 	var bookDataId map[string][]int
 	var response []domain.Book
 	if err := json.NewDecoder(r.Body).Decode(&bookDataId); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeDecodeError(w, err)
 		return
 	}
 	bookIdSlice := bookDataId["data"]
-	for _, bookId := range bookIdSlice {
-		book, err := h.bookService.DeleteBookById(bookId)
+	// The optional versions hold the expected version of each book.
+	versions, hasVersions := bookDataId["versions"]
+	if hasVersions && len(versions) != len(bookIdSlice) {
+		writeServiceError(w, domain.NewValidationError("versions", "must have one version per book id"))
+		return
+	}
+	for index, bookId := range bookIdSlice {
+		version := 0
+		if hasVersions {
+			version = versions[index]
+		}
+		book, err := h.bookService.DeleteBookById(r.Context(), bookId, version)
 		if err != nil {
-			http.Error(w, "Error deleting book", http.StatusInternalServerError)
+			writeServiceError(w, err)
+			return
 		}
 		response = append(response, book)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	writeJSON(w, http.StatusOK, response)
 }
 
 func (h *BookHandler) DeleteBookByIdHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	bookId, err := strconv.Atoi(vars["bookId"])
 	if err != nil {
-		http.Error(w, "Invalid book id", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "Invalid book id")
 		return
 	}
 
-	book, err := h.bookService.DeleteBookById(bookId)
+	version, err := h.ifMatchVersion(r, bookId)
 	if err != nil {
-		http.Error(w, "Error deleting book", http.StatusInternalServerError)
+		writeServiceError(w, err)
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(book)
+	book, err := h.bookService.DeleteBookById(r.Context(), bookId, version)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, book)
 }
 
 func (h *BookHandler) UpdateBookByIdHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	bookId, err := strconv.Atoi(vars["bookId"])
 	if err != nil {
-		http.Error(w, "Invalid book id", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "Invalid book id")
+		return
+	}
+
+	version, err := h.ifMatchVersion(r, bookId)
+	if err != nil {
+		writeServiceError(w, err)
+		return
 	}
 
 	var bookData map[string][]string
 	err = json.NewDecoder(r.Body).Decode(&bookData)
 	if err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeDecodeError(w, err)
 		return
 	}
 
-	book, err := h.bookService.UpdateBookById(bookId, bookData)
+	book, err := h.bookService.UpdateBookById(r.Context(), bookId, bookData, version)
 	if err != nil {
-		log.Fatal("Update fail", err)
+		writeServiceError(w, err)
+		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(book)
+	w.Header().Set("ETag", bookETag(book))
+	writeJSON(w, http.StatusOK, book)
 }
 
 func (h *BookHandler) UpdateMultipleBookByIdHandler(w http.ResponseWriter, r *http.Request) {
 	var bookDataList []map[string][]string
 	var response []domain.Book
 	if err := json.NewDecoder(r.Body).Decode(&bookDataList); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeDecodeError(w, err)
 		return
 	}
 
 	for _, bookData := range bookDataList {
 		if bookId, isContainsBookId := bookData["id"]; isContainsBookId {
+			if len(bookId) == 0 {
+				writeError(w, http.StatusBadRequest, "Invalid request body")
+				return
+			}
 			bookIdInt, err := strconv.Atoi(bookId[0])
 			delete(bookData, "id")
 			if err != nil {
-				http.Error(w, "Invalid request body", http.StatusBadRequest)
+				writeError(w, http.StatusBadRequest, "Invalid request body")
 				return
 			}
-			book, err := h.bookService.UpdateBookById(bookIdInt, bookData)
+			// The optional version is the version the book is expected at.
+			version := 0
+			if versionValue, hasVersion := bookData["version"]; hasVersion {
+				delete(bookData, "version")
+				if len(versionValue) == 0 {
+					writeServiceError(w, domain.NewValidationError("version", "must not be empty"))
+					return
+				}
+				if version, err = strconv.Atoi(versionValue[0]); err != nil {
+					writeServiceError(w, domain.NewValidationError("version", "must be an integer"))
+					return
+				}
+			}
+			book, err := h.bookService.UpdateBookById(r.Context(), bookIdInt, bookData, version)
 			if err != nil {
-				http.Error(w, "Update failed", http.StatusBadRequest)
+				writeServiceError(w, err)
 				return
 			}
 			response = append(response, book)
 		}
 	}
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (h *BookHandler) GetDeletedBooksHandler(w http.ResponseWriter, r *http.Request) {
+	books, err := h.bookService.GetDeletedBooks(r.Context())
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, books)
+}
+
+func (h *BookHandler) RestoreBookByIdHandler(w http.ResponseWriter, r *http.Request) {
+	bookId, err := strconv.Atoi(mux.Vars(r)["bookId"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid book id")
+		return
+	}
+
+	book, err := h.bookService.RestoreBookById(r.Context(), bookId)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, book)
+}
+
+func (h *BookHandler) GetBookHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	bookId, err := strconv.Atoi(mux.Vars(r)["bookId"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid book id")
+		return
+	}
+
+	revisions, err := h.bookService.GetBookHistory(r.Context(), bookId)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, revisions)
+}
+
+func (h *BookHandler) GetBookRevisionHandler(w http.ResponseWriter, r *http.Request) {
+	bookId, revision, ok := bookRevisionVars(w, r)
+	if !ok {
+		return
+	}
+	from, err := intQueryParam(r.URL.Query(), "from")
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	bookRevision, err := h.bookService.GetBookRevision(r.Context(), bookId, revision, from)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, bookRevision)
+}
+
+func (h *BookHandler) RevertBookHandler(w http.ResponseWriter, r *http.Request) {
+	bookId, revision, ok := bookRevisionVars(w, r)
+	if !ok {
+		return
+	}
+
+	version, err := h.ifMatchVersion(r, bookId)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	book, err := h.bookService.RevertBook(r.Context(), bookId, revision, version)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.Header().Set("ETag", bookETag(book))
+	writeJSON(w, http.StatusOK, book)
+}
+
+// ifMatchVersion returns the version the If-Match header requires of book
+// bookId, 0 when the header is missing or "*". The repository refuses the
+// change if the book moves past that version before it is written.
+func (h *BookHandler) ifMatchVersion(r *http.Request, bookId int) (int, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+	book, err := h.bookService.GetBookById(r.Context(), bookId)
+	if err != nil {
+		return 0, err
+	}
+	return matchETag(header, book)
+}
+
+func bookRevisionVars(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	vars := mux.Vars(r)
+	bookId, err := strconv.Atoi(vars["bookId"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid book id")
+		return 0, 0, false
+	}
+	revision, err := strconv.Atoi(vars["revision"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid revision")
+		return 0, 0, false
+	}
+	return bookId, revision, true
 }
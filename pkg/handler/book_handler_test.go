@@ -0,0 +1,229 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/hoaibao/book-management/pkg/handler"
+	"github.com/hoaibao/book-management/pkg/repository"
+	"github.com/hoaibao/book-management/pkg/router"
+	"github.com/hoaibao/book-management/pkg/service"
+)
+
+// newRouter serves the book and author endpoints from the in-memory
+// repositories, with book 1 at version 1 written by author 1.
+func newRouter(t *testing.T) *mux.Router {
+	t.Helper()
+	authorRepository := repository.NewInMemoryAuthorRepository()
+	bookRepository := repository.NewInMemoryBookRepository(authorRepository)
+
+	mainRouter := router.SetMainRouter()
+	router.SetBookRouter(handler.NewBookHandler(service.NewBookService(bookRepository)), mainRouter)
+	router.SetAuthorRouter(handler.NewAuthorHandler(service.NewAuthorService(authorRepository, bookRepository)), mainRouter)
+
+	response := serve(mainRouter, http.MethodPost, "/api/v3/books",
+		`[{"name": ["Go"], "isbn": ["9780306406157"], "author": ["Alan"], "publishYear": ["2015"]}]`, nil)
+	if response.Code != http.StatusOK {
+		t.Fatalf("creating the book: status %d, body %s", response.Code, response.Body)
+	}
+	return mainRouter
+}
+
+func serve(handler http.Handler, method, target, body string, header map[string]string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, target, strings.NewReader(body))
+	for key, value := range header {
+		request.Header.Set(key, value)
+	}
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+	return response
+}
+
+func TestErrorStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		header map[string]string
+		status int
+		field  string
+	}{
+		{name: "invalid book id", method: http.MethodGet, target: "/api/v3/books/one", status: http.StatusBadRequest},
+		{name: "unknown book", method: http.MethodGet, target: "/api/v3/books/99", status: http.StatusNotFound},
+		{name: "update unknown book", method: http.MethodPut, target: "/api/v3/books/99", body: `{"name": ["C"]}`, status: http.StatusNotFound},
+		{name: "unknown author", method: http.MethodGet, target: "/api/v3/authors/99", status: http.StatusNotFound},
+		{name: "unknown revision", method: http.MethodGet, target: "/api/v3/books/1/history/9", status: http.StatusNotFound},
+
+		{
+			name: "duplicate isbn", method: http.MethodPost, target: "/api/v3/books",
+			body:   `[{"name": ["C"], "isbn": ["0-306-40615-2"], "author": ["Bob"], "publishYear": ["2001"]}]`,
+			status: http.StatusConflict,
+		},
+		{name: "author with books", method: http.MethodDelete, target: "/api/v3/authors/1", status: http.StatusConflict},
+		{name: "duplicate author name", method: http.MethodPost, target: "/api/v3/authors", body: `{"name": "Alan"}`, status: http.StatusConflict},
+
+		{
+			name: "stale if-match", method: http.MethodPut, target: "/api/v3/books/1", body: `{"name": ["C"]}`,
+			header: map[string]string{"If-Match": `"2"`}, status: http.StatusPreconditionFailed,
+		},
+		{
+			name: "stale if-match on delete", method: http.MethodDelete, target: "/api/v3/books/1",
+			header: map[string]string{"If-Match": `"2"`}, status: http.StatusPreconditionFailed,
+		},
+		{
+			name: "if-match of the version with another hash", method: http.MethodPut, target: "/api/v3/books/1", body: `{"name": ["C"]}`,
+			header: map[string]string{"If-Match": `"1-deadbeefdeadbeef"`}, status: http.StatusPreconditionFailed,
+		},
+		{
+			name: "if-match of the bare version", method: http.MethodPut, target: "/api/v3/books/1", body: `{"name": ["C"]}`,
+			header: map[string]string{"If-Match": `"1"`}, status: http.StatusPreconditionFailed,
+		},
+		{
+			name: "malformed if-match", method: http.MethodPut, target: "/api/v3/books/1", body: `{"name": ["C"]}`,
+			header: map[string]string{"If-Match": "version"}, status: http.StatusPreconditionFailed,
+		},
+
+		{
+			name: "invalid isbn", method: http.MethodPost, target: "/api/v3/books",
+			body:   `[{"name": ["C"], "isbn": ["9780306406158"], "author": ["Bob"], "publishYear": ["2001"]}]`,
+			status: http.StatusUnprocessableEntity, field: "isbn",
+		},
+		{
+			name: "publish year not a number", method: http.MethodPut, target: "/api/v3/books/1", body: `{"publishYear": ["soon"]}`,
+			status: http.StatusUnprocessableEntity, field: "publishYear",
+		},
+		{name: "empty name", method: http.MethodPut, target: "/api/v3/books/1", body: `{"name": [" "]}`, status: http.StatusUnprocessableEntity, field: "name"},
+		{name: "unknown field", method: http.MethodPut, target: "/api/v3/books/1", body: `{"price": ["10"]}`, status: http.StatusUnprocessableEntity, field: "price"},
+		{name: "empty author name", method: http.MethodPost, target: "/api/v3/authors", body: `{"name": ""}`, status: http.StatusUnprocessableEntity, field: "name"},
+		{name: "limit too large", method: http.MethodGet, target: "/api/v3/books?limit=101", status: http.StatusUnprocessableEntity, field: "limit"},
+		{name: "invalid cursor", method: http.MethodGet, target: "/api/v3/books?cursor=x", status: http.StatusUnprocessableEntity, field: "cursor"},
+		{name: "cursor and offset", method: http.MethodGet, target: "/api/v3/books?cursor=eyJvIjoxfQ&offset=1", status: http.StatusUnprocessableEntity, field: "cursor"},
+		{name: "unknown sort", method: http.MethodGet, target: "/api/v3/books?sort=price", status: http.StatusUnprocessableEntity, field: "sort"},
+		{name: "invalid birthday", method: http.MethodPost, target: "/api/v3/authors", body: `{"name": "Bob", "birthDay": "31/01/1970"}`, status: http.StatusUnprocessableEntity, field: "birthDay"},
+		{name: "publish year filter not a number", method: http.MethodGet, target: "/api/v3/books?from=soon&to=2020", status: http.StatusUnprocessableEntity, field: "from"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response := serve(newRouter(t), test.method, test.target, test.body, test.header)
+			if response.Code != test.status {
+				t.Fatalf("status = %d, want %d, body %s", response.Code, test.status, response.Body)
+			}
+			var body struct {
+				Status int    `json:"status"`
+				Field  string `json:"field"`
+			}
+			if err := json.Unmarshal(response.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decoding the error %s: %v", response.Body, err)
+			}
+			if body.Status != test.status || body.Field != test.field {
+				t.Errorf("error = %+v, want status %d and field %q", body, test.status, test.field)
+			}
+		})
+	}
+}
+
+func TestPagination(t *testing.T) {
+	mainRouter := newRouter(t)
+	for _, isbn := range []string{"9780131103627", "9780201633610", "9780262033848", "9780596007126"} {
+		body := `[{"name": ["Book"], "isbn": ["` + isbn + `"], "author": ["Alan"], "publishYear": ["2000"]}]`
+		if response := serve(mainRouter, http.MethodPost, "/api/v3/books", body, nil); response.Code != http.StatusOK {
+			t.Fatalf("creating a book: status %d, body %s", response.Code, response.Body)
+		}
+	}
+
+	// Following the next links walks every book once, in order.
+	var ids []int
+	target := "/api/v3/books?limit=2"
+	for pages := 0; target != ""; pages++ {
+		if pages == 5 {
+			t.Fatal("too many pages")
+		}
+		response := serve(mainRouter, http.MethodGet, target, "", nil)
+		if response.Code != http.StatusOK {
+			t.Fatalf("GET %s: status %d, body %s", target, response.Code, response.Body)
+		}
+		var page struct {
+			Books []struct {
+				Id int `json:"id"`
+			} `json:"books"`
+			Total int     `json:"total"`
+			Limit int     `json:"limit"`
+			Next  *string `json:"next"`
+			Prev  *string `json:"prev"`
+		}
+		if err := json.Unmarshal(response.Body.Bytes(), &page); err != nil {
+			t.Fatalf("decoding the page %s: %v", response.Body, err)
+		}
+		if page.Total != 5 || page.Limit != 2 {
+			t.Errorf("GET %s: total %d and limit %d, want 5 and 2", target, page.Total, page.Limit)
+		}
+		if (page.Prev != nil) != (pages > 0) {
+			t.Errorf("GET %s: prev = %v", target, page.Prev)
+		}
+		for _, book := range page.Books {
+			ids = append(ids, book.Id)
+		}
+		target = ""
+		if page.Next != nil {
+			target = *page.Next
+		}
+	}
+	if want := []int{1, 2, 3, 4, 5}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("ids = %v, want %v", ids, want)
+	}
+}
+
+func TestETag(t *testing.T) {
+	mainRouter := newRouter(t)
+	etag := serve(mainRouter, http.MethodGet, "/api/v3/books/1", "", nil).Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("GET returned no ETag")
+	}
+
+	if response := serve(mainRouter, http.MethodGet, "/api/v3/books/1", "", map[string]string{"If-None-Match": etag}); response.Code != http.StatusNotModified {
+		t.Errorf("GET with the current ETag: status %d, want %d", response.Code, http.StatusNotModified)
+	}
+
+	response := serve(mainRouter, http.MethodPut, "/api/v3/books/1", `{"name": ["C"]}`, map[string]string{"If-Match": etag})
+	if response.Code != http.StatusOK {
+		t.Fatalf("PUT with the current ETag: status %d, want %d", response.Code, http.StatusOK)
+	}
+	if updated := response.Header().Get("ETag"); updated == "" || updated == etag {
+		t.Errorf("PUT returned the ETag %q, want a new one", updated)
+	}
+
+	if response := serve(mainRouter, http.MethodPut, "/api/v3/books/1", `{"name": ["D"]}`, map[string]string{"If-Match": etag}); response.Code != http.StatusPreconditionFailed {
+		t.Errorf("PUT with the previous ETag: status %d, want %d", response.Code, http.StatusPreconditionFailed)
+	}
+	if response := serve(mainRouter, http.MethodGet, "/api/v3/books/1", "", map[string]string{"If-None-Match": etag}); response.Code != http.StatusOK {
+		t.Errorf("GET with the previous ETag: status %d, want %d", response.Code, http.StatusOK)
+	}
+
+	// Renaming the author changes the book's body, so its ETag too.
+	etag = serve(mainRouter, http.MethodGet, "/api/v3/books/1", "", nil).Header().Get("ETag")
+	if response := serve(mainRouter, http.MethodPut, "/api/v3/authors/1", `{"name": "Ada"}`, nil); response.Code != http.StatusOK {
+		t.Fatalf("renaming the author: status %d, body %s", response.Code, response.Body)
+	}
+	if response := serve(mainRouter, http.MethodGet, "/api/v3/books/1", "", map[string]string{"If-None-Match": etag}); response.Code != http.StatusOK {
+		t.Errorf("GET after renaming the author: status %d, want %d", response.Code, http.StatusOK)
+	}
+	if response := serve(mainRouter, http.MethodPut, "/api/v3/books/1", `{"name": ["D"]}`, map[string]string{"If-Match": etag}); response.Code != http.StatusPreconditionFailed {
+		t.Errorf("PUT with the ETag of the same version: status %d, want %d", response.Code, http.StatusPreconditionFailed)
+	}
+
+	// If-Match takes a list of tags, or any tag at all.
+	etag = serve(mainRouter, http.MethodGet, "/api/v3/books/1", "", nil).Header().Get("ETag")
+	response = serve(mainRouter, http.MethodPut, "/api/v3/books/1", `{"name": ["D"]}`, map[string]string{"If-Match": `"1-deadbeefdeadbeef", ` + etag})
+	if response.Code != http.StatusOK {
+		t.Errorf("PUT with the current ETag in a list: status %d, want %d", response.Code, http.StatusOK)
+	}
+	if response := serve(mainRouter, http.MethodPut, "/api/v3/books/1", `{"name": ["E"]}`, map[string]string{"If-Match": "*"}); response.Code != http.StatusOK {
+		t.Errorf("PUT with If-Match *: status %d, want %d", response.Code, http.StatusOK)
+	}
+}
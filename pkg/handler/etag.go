@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/hoaibao/book-management/pkg/domain"
+)
+
+// bookETag is the strong entity tag of a book: its version followed by a
+// hash of its JSON. The hash changes with the authors, which are renamed
+// without bumping the version of their books.
+func bookETag(book domain.Book) string {
+	body, _ := json.Marshal(book)
+	sum := sha256.Sum256(body)
+	return strconv.Quote(strconv.Itoa(book.Version) + "-" + hex.EncodeToString(sum[:8]))
+}
+
+// matchETag returns the version of book when the If-Match header lists
+// its current tag, as one tag or in a comma separated list.
+func matchETag(header string, book domain.Book) (int, error) {
+	etag := bookETag(book)
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimSpace(tag) == etag {
+			return book.Version, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: If-Match %s is not the current tag of book %d", domain.ErrPreconditionFailed, header, book.Id)
+}
+
+// notModified reports whether the If-None-Match header matches etag.
+func notModified(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/hoaibao/book-management/pkg/domain"
+)
+
+type errorResponse struct {
+	Status  int    `json:"status"`
+	Error   string `json:"error"`
+	Message string `json:"message"`
+	Field   string `json:"field,omitempty"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorResponse{
+		Status:  status,
+		Error:   http.StatusText(status),
+		Message: message,
+	})
+}
+
+// writeDecodeError reports a request body that can't be decoded, which is
+// 413 when it is over the size limit and 400 otherwise.
+func writeDecodeError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body is larger than %d bytes", maxBytesErr.Limit))
+		return
+	}
+	writeError(w, http.StatusBadRequest, "Invalid request body")
+}
+
+// writeServiceError reports an error returned by a service with the status
+// code matching its domain error. Unknown errors are not leaked to clients.
+func writeServiceError(w http.ResponseWriter, err error) {
+	var validationErr *domain.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		writeJSON(w, http.StatusUnprocessableEntity, errorResponse{
+			Status:  http.StatusUnprocessableEntity,
+			Error:   http.StatusText(http.StatusUnprocessableEntity),
+			Message: validationErr.Error(),
+			Field:   validationErr.Field,
+		})
+	case errors.Is(err, domain.ErrValidation):
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, domain.ErrNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, domain.ErrDuplicateISBN), errors.Is(err, domain.ErrConflict):
+		writeError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, domain.ErrPreconditionFailed):
+		writeError(w, http.StatusPreconditionFailed, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		writeError(w, http.StatusGatewayTimeout, "request timed out")
+	default:
+		writeError(w, http.StatusInternalServerError, "internal server error")
+	}
+}
@@ -0,0 +1,78 @@
+// Package health runs the checks telling whether the server is ready to
+// serve requests.
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// checkTimeout bounds every check, so a hanging dependency fails its check
+// instead of the probe.
+const checkTimeout = 2 * time.Second
+
+type CheckFunc func(ctx context.Context) error
+
+type Result struct {
+	Status  string `json:"status"`
+	Latency string `json:"latency"`
+	Error   string `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+type Checker struct {
+	names  []string
+	checks []CheckFunc
+}
+
+func NewChecker() *Checker {
+	return &Checker{}
+}
+
+// Add registers a check. Checks must be added before Run is first called.
+func (c *Checker) Add(name string, check CheckFunc) {
+	c.names = append(c.names, name)
+	c.checks = append(c.checks, check)
+}
+
+// Run runs every check concurrently. The report fails when any check does.
+func (c *Checker) Run(ctx context.Context) Report {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	results := make([]Result, len(c.checks))
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func(i int, check CheckFunc) {
+			defer wg.Done()
+			start := time.Now()
+			err := check(ctx)
+			results[i] = Result{Status: StatusOK, Latency: time.Since(start).String()}
+			if err != nil {
+				results[i].Status = StatusFail
+				results[i].Error = err.Error()
+			}
+		}(i, check)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(results))}
+	for i, result := range results {
+		report.Checks[c.names[i]] = result
+		if result.Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
@@ -0,0 +1,108 @@
+// Package isbn parses ISBN-10 and ISBN-13 numbers. Books store the canonical
+// form returned by Parse: the ISBN-13 digits without hyphens or spaces.
+package isbn
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrInvalidLength    = errors.New("must have 10 or 13 digits")
+	ErrInvalidCharacter = errors.New("must contain only digits, hyphens and spaces, with an optional X check digit for ISBN-10")
+	ErrInvalidPrefix    = errors.New("ISBN-13 must start with 978 or 979")
+	ErrInvalidChecksum  = errors.New("has an invalid check digit")
+)
+
+// Parse validates an ISBN-10 or ISBN-13 written with or without hyphens and
+// returns its canonical ISBN-13 form.
+func Parse(value string) (string, error) {
+	digits := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		if r == 'x' {
+			return 'X'
+		}
+		return r
+	}, value)
+
+	switch len(digits) {
+	case 10:
+		if err := validate10(digits); err != nil {
+			return "", err
+		}
+		return To13(digits), nil
+	case 13:
+		if err := validate13(digits); err != nil {
+			return "", err
+		}
+		return digits, nil
+	}
+	return "", ErrInvalidLength
+}
+
+// IsValid reports whether value is a valid ISBN-10 or ISBN-13.
+func IsValid(value string) bool {
+	_, err := Parse(value)
+	return err == nil
+}
+
+// To13 converts the digits of a valid ISBN-10 to ISBN-13 by adding the 978
+// prefix and recomputing the check digit.
+func To13(isbn10 string) string {
+	prefix := "978" + isbn10[:9]
+	return prefix + string(checkDigit13(prefix))
+}
+
+func validate10(digits string) error {
+	sum := 0
+	for i := 0; i < 10; i++ {
+		var value int
+		switch {
+		case isDigit(digits[i]):
+			value = int(digits[i] - '0')
+		case digits[i] == 'X' && i == 9:
+			value = 10
+		default:
+			return ErrInvalidCharacter
+		}
+		sum += value * (10 - i)
+	}
+	if sum%11 != 0 {
+		return ErrInvalidChecksum
+	}
+	return nil
+}
+
+func validate13(digits string) error {
+	for i := 0; i < 13; i++ {
+		if !isDigit(digits[i]) {
+			return ErrInvalidCharacter
+		}
+	}
+	if !strings.HasPrefix(digits, "978") && !strings.HasPrefix(digits, "979") {
+		return ErrInvalidPrefix
+	}
+	if checkDigit13(digits[:12]) != digits[12] {
+		return ErrInvalidChecksum
+	}
+	return nil
+}
+
+// checkDigit13 computes the check digit of the first 12 digits of an ISBN-13.
+func checkDigit13(digits string) byte {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += int(digits[i]-'0') * weight
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,63 @@
+package isbn
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+		err   error
+	}{
+		{name: "isbn-13", value: "9780306406157", want: "9780306406157"},
+		{name: "isbn-13 with hyphens", value: "978-0-306-40615-7", want: "9780306406157"},
+		{name: "isbn-13 with spaces", value: "978 0 306 40615 7", want: "9780306406157"},
+		{name: "isbn-13 with 979 prefix", value: "9791000000008", want: "9791000000008"},
+		{name: "isbn-10", value: "0306406152", want: "9780306406157"},
+		{name: "isbn-10 with hyphens", value: "0-306-40615-2", want: "9780306406157"},
+		{name: "isbn-10 with X check digit", value: "080442957X", want: "9780804429573"},
+		{name: "isbn-10 with lowercase x", value: "080442957x", want: "9780804429573"},
+		{name: "empty", value: "", err: ErrInvalidLength},
+		{name: "too short", value: "030640615", err: ErrInvalidLength},
+		{name: "too long", value: "97803064061570", err: ErrInvalidLength},
+		{name: "isbn-10 checksum", value: "0306406153", err: ErrInvalidChecksum},
+		{name: "isbn-13 checksum", value: "9780306406158", err: ErrInvalidChecksum},
+		{name: "isbn-10 letter", value: "03064A6152", err: ErrInvalidCharacter},
+		{name: "isbn-10 X before the end", value: "0306X06152", err: ErrInvalidCharacter},
+		{name: "isbn-13 X check digit", value: "978030640615X", err: ErrInvalidCharacter},
+		{name: "isbn-13 prefix", value: "9770306406157", err: ErrInvalidPrefix},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Parse(test.value)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("Parse(%q) error = %v, want %v", test.value, err, test.err)
+			}
+			if got != test.want {
+				t.Errorf("Parse(%q) = %q, want %q", test.value, got, test.want)
+			}
+			if valid := IsValid(test.value); valid != (test.err == nil) {
+				t.Errorf("IsValid(%q) = %t", test.value, valid)
+			}
+		})
+	}
+}
+
+func TestTo13(t *testing.T) {
+	tests := []struct {
+		isbn10 string
+		want   string
+	}{
+		{isbn10: "0306406152", want: "9780306406157"},
+		{isbn10: "1861972717", want: "9781861972712"},
+		{isbn10: "080442957X", want: "9780804429573"},
+	}
+	for _, test := range tests {
+		if got := To13(test.isbn10); got != test.want {
+			t.Errorf("To13(%q) = %q, want %q", test.isbn10, got, test.want)
+		}
+	}
+}
@@ -1,9 +1,10 @@
 package logger
 
 import (
-	"fmt"
+	"context"
 	"log"
 	"os"
+	"path/filepath"
 	"time"
 
 	"go.uber.org/zap"
@@ -13,22 +14,119 @@ import (
 type Logger struct {
 	FileLogger    *zap.SugaredLogger
 	ConsoleLogger *zap.SugaredLogger
+	// file is the log file of FileLogger.
+	file *rotatingFile
 }
 
-func InitLogger() Logger {
-	fileLogger := InitFileLogger()
-	consoleLogger := InitConsoleLogger()
+// fileName is the name of the current log file. Rotated files get the time
+// of their rotation added to it.
+const fileName = "book-management.log"
+
+type Config struct {
+	// Dir is the directory of the log files.
+	Dir string
+	// Level is the minimum level logged, such as "info".
+	Level string
+	// MaxSizeMB is the size at which the log file is rotated.
+	MaxSizeMB int
+	// RotateInterval rotates the log file periodically, whatever its size.
+	// Zero disables it.
+	RotateInterval time.Duration
+	// MaxBackups and MaxAgeDays limit the rotated files kept, zero keeping
+	// them all.
+	MaxBackups int
+	MaxAgeDays int
+	// Compress gzips the rotated files.
+	Compress bool
+}
+
+func InitLogger(config Config) Logger {
+	level, err := zapcore.ParseLevel(config.Level)
+	if err != nil {
+		log.Fatal("Invalid log level", err)
+	}
+	if err := os.MkdirAll(config.Dir, 0o755); err != nil {
+		log.Fatal("Can't create log directory", err)
+	}
+	file := newRotatingFile(filepath.Join(config.Dir, fileName), config)
+	fileLogger := newFileLogger(file, level)
+	consoleLogger := InitConsoleLogger(level)
 	return Logger{
 		ConsoleLogger: consoleLogger,
 		FileLogger:    fileLogger,
+		file:          file,
 	}
 }
 
-func InitFileLogger() *zap.SugaredLogger {
-	writeSync := getLogWriter()
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying l.
+func NewContext(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the Logger carried by ctx, or fallback when it has
+// none.
+func FromContext(ctx context.Context, fallback Logger) Logger {
+	if l, ok := ctx.Value(contextKey{}).(Logger); ok {
+		return l
+	}
+	return fallback
+}
+
+// With adds the key-value pairs to every entry logged by the returned
+// Logger.
+func (l Logger) With(keysAndValues ...interface{}) Logger {
+	l.FileLogger = l.FileLogger.With(keysAndValues...)
+	l.ConsoleLogger = l.ConsoleLogger.With(keysAndValues...)
+	return l
+}
+
+// Sync flushes the buffered log entries. Only the file is synced, the
+// console is stderr, which can't be synced on most terminals.
+func (l Logger) Sync() error {
+	return l.FileLogger.Sync()
+}
+
+// Close flushes the log file and closes it. Entries logged afterwards
+// reopen it.
+func (l Logger) Close() error {
+	if l.file == nil {
+		return nil
+	}
+	if err := l.Sync(); err != nil {
+		return err
+	}
+	return l.file.Close()
+}
+
+// Writable reports an error when the log directory has been removed or its
+// disk can't take more files.
+func (l Logger) Writable() error {
+	if l.file == nil {
+		return nil
+	}
+	probe, err := os.CreateTemp(filepath.Dir(l.file.Filename), ".writable-*")
+	if err != nil {
+		return err
+	}
+	probe.Close()
+	return os.Remove(probe.Name())
+}
+
+// NewNop returns a Logger that discards everything.
+func NewNop() Logger {
+	return Logger{
+		FileLogger:    zap.NewNop().Sugar(),
+		ConsoleLogger: zap.NewNop().Sugar(),
+	}
+}
+
+func newFileLogger(file *rotatingFile, level zapcore.Level) *zap.SugaredLogger {
+	writeSync := zapcore.AddSync(file)
 	encoder := getEncoder()
 
-	zapCore := zapcore.NewCore(encoder, writeSync, zapcore.DebugLevel)
+	zapCore := zapcore.NewCore(encoder, writeSync, level)
 	fileLogger := zap.New(zapCore, zap.AddCaller())
 	return fileLogger.Sugar()
 }
@@ -47,39 +145,30 @@ func getEncoder() zapcore.Encoder {
 		* 2021-07-17 14:52:35	[INFO]	golangforteaching/main.go:10	Today is :2021-July-17
 	*/
 	return zapcore.NewConsoleEncoder(zapcore.EncoderConfig{
-		MessageKey:   "message",
-		TimeKey:      "time",
-		CallerKey:    "caller",
-		LevelKey:     "level",
-		EncodeLevel:  customLevelEncoder,
-		EncodeTime:   syslogTimeEncoder,
-		EncodeCaller: zapcore.ShortCallerEncoder,
+		MessageKey:     "message",
+		TimeKey:        "time",
+		CallerKey:      "caller",
+		LevelKey:       "level",
+		EncodeLevel:    customLevelEncoder,
+		EncodeTime:     syslogTimeEncoder,
+		EncodeCaller:   zapcore.ShortCallerEncoder,
+		EncodeDuration: zapcore.StringDurationEncoder,
 	})
 }
 
-func getLogWriter() zapcore.WriteSyncer {
-	current_date := time.Now().Format("02-01-2006")
-	current_time := time.Now().Format("15-04-05")
-	outputFileName := fmt.Sprintf("pkg/logger/logger-files/log_%s_%s.txt", current_date, current_time)
-	file, err := os.Create(outputFileName)
-	if err != nil {
-		log.Fatal("Can't open log file", err)
-	}
-	return zapcore.AddSync(file)
-}
-
-func InitConsoleLogger() *zap.SugaredLogger {
+func InitConsoleLogger(level zapcore.Level) *zap.SugaredLogger {
 	cfg := zap.Config{
 		Encoding:    "console", // json or console
-		Level:       zap.NewAtomicLevelAt(zap.InfoLevel),
+		Level:       zap.NewAtomicLevelAt(level),
 		OutputPaths: []string{"stderr"},
 		EncoderConfig: zapcore.EncoderConfig{
-			MessageKey:   "message",
-			TimeKey:      "time",
-			CallerKey:    "caller",
-			EncodeCaller: zapcore.FullCallerEncoder,
-			EncodeLevel:  customLevelEncoder,
-			EncodeTime:   syslogTimeEncoder,
+			MessageKey:     "message",
+			TimeKey:        "time",
+			CallerKey:      "caller",
+			EncodeCaller:   zapcore.FullCallerEncoder,
+			EncodeDuration: zapcore.StringDurationEncoder,
+			EncodeLevel:    customLevelEncoder,
+			EncodeTime:     syslogTimeEncoder,
 		},
 	}
 	consoleLogger, err := cfg.Build()
@@ -0,0 +1,57 @@
+package logger
+
+import (
+	"sync"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// rotatingFile is the log file. lumberjack rotates it once it reaches the
+// maximum size and removes the rotated files beyond the retained count and
+// age; rotatingFile also rotates it at every multiple of the rotation
+// interval, such as every midnight UTC for 24h.
+type rotatingFile struct {
+	*lumberjack.Logger
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func newRotatingFile(filename string, config Config) *rotatingFile {
+	file := &rotatingFile{
+		Logger: &lumberjack.Logger{
+			Filename:   filename,
+			MaxSize:    config.MaxSizeMB,
+			MaxBackups: config.MaxBackups,
+			MaxAge:     config.MaxAgeDays,
+			Compress:   config.Compress,
+		},
+		stop: make(chan struct{}),
+	}
+	if config.RotateInterval > 0 {
+		go file.rotateEvery(config.RotateInterval)
+	}
+	return file
+}
+
+func (f *rotatingFile) rotateEvery(interval time.Duration) {
+	for {
+		now := time.Now()
+		timer := time.NewTimer(now.Truncate(interval).Add(interval).Sub(now))
+		select {
+		case <-f.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+			f.Rotate()
+		}
+	}
+}
+
+// Close stops the timed rotation and closes the file.
+func (f *rotatingFile) Close() error {
+	f.stopOnce.Do(func() {
+		close(f.stop)
+	})
+	return f.Logger.Close()
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/hoaibao/book-management/pkg/logger"
+)
+
+// Logging logs every request once it is answered, and gives the handlers a
+// logger tagged with the request id, available through logger.FromContext.
+// It must run after RequestID.
+func Logging(log logger.Logger) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			requestLogger := log.With("requestId", RequestIDFromContext(r.Context()))
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+			next.ServeHTTP(recorder, r.WithContext(logger.NewContext(r.Context(), requestLogger)))
+
+			keysAndValues := []interface{}{
+				"method", r.Method,
+				"path", r.URL.Path,
+				"status", recorder.status,
+				"bytes", recorder.bytes,
+				"duration", time.Since(start),
+			}
+			requestLogger.ConsoleLogger.Infow("Request", keysAndValues...)
+			requestLogger.FileLogger.Infow("Request", keysAndValues...)
+		})
+	}
+}
+
+// statusRecorder remembers the status and the size of a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hoaibao/book-management/pkg/logger"
+)
+
+func TestLogging(t *testing.T) {
+	log, logs := observedLogger()
+	handler := RequestID(Logging(log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.FromContext(r.Context(), logger.NewNop()).FileLogger.Info("Creating the book")
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, "hello")
+	})))
+
+	request := httptest.NewRequest(http.MethodPost, "/api/v3/books", nil)
+	request.Header.Set(RequestIDHeader, "client-42")
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+
+	entries := logs.All()
+	if len(entries) != 2 {
+		t.Fatalf("logged %d entries, want 2", len(entries))
+	}
+	if fields := entries[0].ContextMap(); entries[0].Message != "Creating the book" || fields["requestId"] != "client-42" {
+		t.Errorf("handler entry %q with fields %v, want the request id", entries[0].Message, fields)
+	}
+	fields := entries[1].ContextMap()
+	if entries[1].Message != "Request" || fields["requestId"] != "client-42" || fields["method"] != http.MethodPost ||
+		fields["path"] != "/api/v3/books" || fields["status"] != int64(http.StatusCreated) || fields["bytes"] != int64(5) {
+		t.Errorf("request entry %q with fields %v", entries[1].Message, fields)
+	}
+}
+
+func TestLoggingWithoutWriteHeader(t *testing.T) {
+	log, logs := observedLogger()
+	handler := RequestID(Logging(log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	})))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health/live", nil))
+
+	entries := logs.FilterMessage("Request").All()
+	if len(entries) != 1 || entries[0].ContextMap()["status"] != int64(http.StatusOK) {
+		t.Errorf("request entries %v, want one with status 200", entries)
+	}
+}
+
+func TestRecoveryLogsTheRequestID(t *testing.T) {
+	log, logs := observedLogger()
+	handler := RequestID(Logging(log)(Recovery(log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))))
+
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	requestID := response.Header().Get(RequestIDHeader)
+	for _, message := range []string{"Recovered from panic", "Request"} {
+		entries := logs.FilterMessage(message).All()
+		if len(entries) != 1 || entries[0].ContextMap()["requestId"] != requestID {
+			t.Errorf("%q entries %v, want one with request id %s", message, entries, requestID)
+		}
+	}
+}
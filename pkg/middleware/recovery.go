@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gorilla/mux"
+	"github.com/hoaibao/book-management/pkg/logger"
+)
+
+// internalServerError is the body of the 500 answered for a panic, in the
+// format of the handler errors.
+const internalServerError = `{"status":500,"error":"Internal Server Error","message":"internal server error"}` + "\n"
+
+// Recovery turns a panic in a handler into a 500 response and logs it with
+// its stack trace, so that one bad request can't take the server down. It
+// logs through the request logger set by Logging when it runs after it.
+func Recovery(log logger.Logger) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				p := recover()
+				if p == nil {
+					return
+				}
+				// The server aborts the response without logging this one.
+				if p == http.ErrAbortHandler {
+					panic(p)
+				}
+
+				keysAndValues := []interface{}{
+					"method", r.Method,
+					"path", r.URL.Path,
+					"panic", p,
+					"stack", string(debug.Stack()),
+				}
+				requestLogger := logger.FromContext(r.Context(), log)
+				requestLogger.ConsoleLogger.Errorw("Recovered from panic", keysAndValues...)
+				requestLogger.FileLogger.Errorw("Recovered from panic", keysAndValues...)
+
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte(internalServerError))
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
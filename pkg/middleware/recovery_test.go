@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hoaibao/book-management/pkg/logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// observedLogger records what is logged to the file logger.
+func observedLogger() (logger.Logger, *observer.ObservedLogs) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	return logger.Logger{FileLogger: zap.New(core).Sugar(), ConsoleLogger: zap.NewNop().Sugar()}, logs
+}
+
+func TestRecovery(t *testing.T) {
+	log, logs := observedLogger()
+	handler := Recovery(log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	request := httptest.NewRequest(http.MethodGet, "/api/v3/books/1", nil)
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+
+	if response.Code != http.StatusInternalServerError || response.Body.String() != internalServerError {
+		t.Errorf("response = %d %s, want 500 %s", response.Code, response.Body, internalServerError)
+	}
+	if contentType := response.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", contentType)
+	}
+
+	entries := logs.FilterMessage("Recovered from panic").All()
+	if len(entries) != 1 {
+		t.Fatalf("logged %d panics, want 1", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["panic"] != "boom" || fields["path"] != "/api/v3/books/1" || fields["method"] != http.MethodGet {
+		t.Errorf("logged fields %v", fields)
+	}
+	if stack, _ := fields["stack"].(string); !strings.Contains(stack, "TestRecovery") {
+		t.Errorf("logged stack doesn't reach the handler:\n%s", stack)
+	}
+}
+
+func TestRecoveryWithoutPanic(t *testing.T) {
+	log, logs := observedLogger()
+	handler := Recovery(log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest(http.MethodPost, "/api/v3/books", nil))
+	if response.Code != http.StatusCreated || logs.Len() != 0 {
+		t.Errorf("status %d with %d log entries, want 201 and nothing logged", response.Code, logs.Len())
+	}
+}
+
+func TestRecoveryLetsAbortHandlerThrough(t *testing.T) {
+	log, logs := observedLogger()
+	handler := Recovery(log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	defer func() {
+		if p := recover(); p != http.ErrAbortHandler {
+			t.Errorf("panic = %v, want %v", p, http.ErrAbortHandler)
+		}
+		if logs.Len() != 0 {
+			t.Errorf("logged %d entries, want the aborted response left to the server", logs.Len())
+		}
+	}()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
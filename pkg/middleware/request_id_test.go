@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var generatedRequestID = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{name: "missing", header: ""},
+		{name: "set by the client", header: "client-42", want: "client-42"},
+		{name: "too long", header: strings.Repeat("a", maxRequestIDLength+1)},
+		{name: "longest", header: strings.Repeat("a", maxRequestIDLength), want: strings.Repeat("a", maxRequestIDLength)},
+		{name: "with a space", header: "client 42"},
+		{name: "with a control character", header: "client\x1b42"},
+		{name: "not ascii", header: "clienté"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var seen string
+			handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = RequestIDFromContext(r.Context())
+			}))
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.header != "" {
+				request.Header.Set(RequestIDHeader, test.header)
+			}
+			response := httptest.NewRecorder()
+			handler.ServeHTTP(response, request)
+
+			sent := response.Header().Get(RequestIDHeader)
+			if sent != seen {
+				t.Errorf("response header %q, handler saw %q", sent, seen)
+			}
+			if test.want != "" && sent != test.want {
+				t.Errorf("request id = %q, want %q", sent, test.want)
+			}
+			if test.want == "" && !generatedRequestID.MatchString(sent) {
+				t.Errorf("request id = %q, want a generated one", sent)
+			}
+		})
+	}
+}
+
+func TestRequestIDsAreUnique(t *testing.T) {
+	seen := make(map[string]bool)
+	handler := RequestID(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	for i := 0; i < 100; i++ {
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/", nil))
+		requestID := response.Header().Get(RequestIDHeader)
+		if seen[requestID] {
+			t.Fatalf("request id %q generated twice", requestID)
+		}
+		seen[requestID] = true
+	}
+}
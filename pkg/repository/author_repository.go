@@ -1,8 +1,27 @@
 package repository
 
-import "github.com/hoaibao/book-management/pkg/domain"
+import (
+	"context"
+	"database/sql"
+
+	"github.com/hoaibao/book-management/pkg/domain"
+)
 
 type AuthorRepository interface {
-	GetAuthorById(id int) (domain.Author, error)
-	GetAuthorByName(name string) (domain.Author, error)
+	GetAllAuthors(ctx context.Context) ([]domain.Author, error)
+	GetAuthorById(ctx context.Context, id int) (domain.Author, error)
+	GetAuthorByName(ctx context.Context, name string) (domain.Author, error)
+	CreateAuthor(ctx context.Context, author domain.Author) (domain.Author, error)
+	UpdateAuthorById(ctx context.Context, id int, author domain.Author) (domain.Author, error)
+	DeleteAuthorById(ctx context.Context, id int) (domain.Author, error)
+}
+
+// AuthorResolver links the SQL book repositories to the authors of the books
+// they write. Both methods run inside tx, the transaction writing the book,
+// so that the authors they insert are rolled back with it.
+type AuthorResolver interface {
+	// ResolveAuthors returns the named authors, inserting the missing ones.
+	ResolveAuthors(ctx context.Context, tx *sql.Tx, names []string) ([]domain.Author, error)
+	// RelinkAuthors returns the authors of a book snapshot as they are now.
+	RelinkAuthors(ctx context.Context, tx *sql.Tx, snapshot []domain.Author) ([]domain.Author, error)
 }
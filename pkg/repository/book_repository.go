@@ -1,11 +1,37 @@
 package repository
 
-import "github.com/hoaibao/book-management/pkg/domain"
+import (
+	"context"
+	"time"
+
+	"github.com/hoaibao/book-management/pkg/domain"
+)
 
 type BookRepository interface {
-	GetAllBooks(isbn, author, fromValue, toValue string) ([]domain.Book, error)
-	GetBookById(id int) (domain.Book, error)
-	CreateBook(book domain.Book, author []string) (domain.Book, error)
-	DeleteBookById(bookId int) (domain.Book, error)
-	UpdateBookById(bookId int, bookData map[string][]string) (domain.Book, error)
+	// GetAllBooks returns the requested page of books and the number of
+	// books matching the filter.
+	GetAllBooks(ctx context.Context, filter domain.BookFilter) ([]domain.Book, int, error)
+	GetBooksByAuthorId(ctx context.Context, authorId int) ([]domain.Book, error)
+	GetBookById(ctx context.Context, id int) (domain.Book, error)
+	CreateBook(ctx context.Context, book domain.Book, author []string) (domain.Book, error)
+	// DeleteBookById moves the book to the trash. Books in the trash are
+	// left out of every other read. A non zero version must match the
+	// version of the book, or ErrPreconditionFailed is returned.
+	DeleteBookById(ctx context.Context, bookId int, version int) (domain.Book, error)
+	// UpdateBookById checks version like DeleteBookById.
+	UpdateBookById(ctx context.Context, bookId int, bookData map[string][]string, version int) (domain.Book, error)
+	// RevertBookById updates the book to snapshot, checking version like
+	// DeleteBookById. The authors of the snapshot are linked again by id;
+	// the ones deleted since are created again from their name.
+	RevertBookById(ctx context.Context, bookId int, snapshot domain.Book, version int) (domain.Book, error)
+	// GetDeletedBooks lists the books in the trash, most recently deleted first.
+	GetDeletedBooks(ctx context.Context) ([]domain.Book, error)
+	RestoreBookById(ctx context.Context, bookId int) (domain.Book, error)
+	// PurgeDeletedBooks removes the books deleted before the given time for
+	// good and returns how many were removed.
+	PurgeDeletedBooks(ctx context.Context, before time.Time) (int, error)
+	// GetBookHistory lists the revisions recorded by every change of the
+	// book, oldest first. Books in the trash keep their history.
+	GetBookHistory(ctx context.Context, bookId int) ([]domain.BookRevision, error)
+	GetBookRevision(ctx context.Context, bookId, revision int) (domain.BookRevision, error)
 }
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/hoaibao/book-management/pkg/cache"
+	"github.com/hoaibao/book-management/pkg/domain"
+)
+
+// CachedBookRepository is a read-through cache of GetBookById in front of
+// another BookRepository. Every change made through it invalidates the
+// cached book; listings are not cached.
+type CachedBookRepository struct {
+	BookRepository
+	books *cache.LRU[int, domain.Book]
+	// generation is bumped by every invalidation, so a read that raced with
+	// a change doesn't put the outdated book back in the cache.
+	generation atomic.Uint64
+}
+
+func NewCachedBookRepository(bookRepository BookRepository, maxSize int, ttl time.Duration) *CachedBookRepository {
+	return &CachedBookRepository{
+		BookRepository: bookRepository,
+		books:          cache.NewLRU[int, domain.Book](maxSize, ttl),
+	}
+}
+
+func (r *CachedBookRepository) Stats() cache.Stats {
+	return r.books.Stats()
+}
+
+func (r *CachedBookRepository) GetBookById(ctx context.Context, id int) (domain.Book, error) {
+	if book, exist := r.books.Get(id); exist {
+		return cloneBook(book), nil
+	}
+
+	generation := r.generation.Load()
+	book, err := r.BookRepository.GetBookById(ctx, id)
+	if err != nil {
+		return domain.Book{}, err
+	}
+	if r.generation.Load() == generation {
+		r.books.Set(id, cloneBook(book))
+	}
+	return book, nil
+}
+
+func (r *CachedBookRepository) CreateBook(ctx context.Context, book domain.Book, author []string) (domain.Book, error) {
+	createdBook, err := r.BookRepository.CreateBook(ctx, book, author)
+	if err == nil {
+		r.invalidate(createdBook.Id)
+	}
+	return createdBook, err
+}
+
+func (r *CachedBookRepository) DeleteBookById(ctx context.Context, bookId int, version int) (domain.Book, error) {
+	defer r.invalidate(bookId)
+	return r.BookRepository.DeleteBookById(ctx, bookId, version)
+}
+
+func (r *CachedBookRepository) UpdateBookById(ctx context.Context, bookId int, bookData map[string][]string, version int) (domain.Book, error) {
+	defer r.invalidate(bookId)
+	return r.BookRepository.UpdateBookById(ctx, bookId, bookData, version)
+}
+
+func (r *CachedBookRepository) RevertBookById(ctx context.Context, bookId int, snapshot domain.Book, version int) (domain.Book, error) {
+	defer r.invalidate(bookId)
+	return r.BookRepository.RevertBookById(ctx, bookId, snapshot, version)
+}
+
+func (r *CachedBookRepository) RestoreBookById(ctx context.Context, bookId int) (domain.Book, error) {
+	defer r.invalidate(bookId)
+	return r.BookRepository.RestoreBookById(ctx, bookId)
+}
+
+func (r *CachedBookRepository) PurgeDeletedBooks(ctx context.Context, before time.Time) (int, error) {
+	defer r.generation.Add(1)
+	return r.BookRepository.PurgeDeletedBooks(ctx, before)
+}
+
+// InvalidateAuthor drops the cached books listing the author, whose name or
+// birthday changed.
+func (r *CachedBookRepository) InvalidateAuthor(authorId int) {
+	r.generation.Add(1)
+	r.books.DeleteFunc(func(_ int, book domain.Book) bool {
+		return slices.ContainsFunc(book.Authors, func(author domain.Author) bool {
+			return author.Id == authorId
+		})
+	})
+}
+
+func (r *CachedBookRepository) invalidate(bookId int) {
+	r.generation.Add(1)
+	r.books.Delete(bookId)
+}
+
+// cloneBook copies the authors of book, so callers can't change the cached
+// book through them.
+func cloneBook(book domain.Book) domain.Book {
+	book.Authors = slices.Clone(book.Authors)
+	return book
+}
+
+// authorCacheInvalidator keeps a CachedBookRepository coherent with the
+// changes made to authors.
+type authorCacheInvalidator struct {
+	AuthorRepository
+	books *CachedBookRepository
+}
+
+// NewAuthorCacheInvalidator wraps authorRepository so that updating an
+// author invalidates the books cached by books.
+func NewAuthorCacheInvalidator(authorRepository AuthorRepository, books *CachedBookRepository) AuthorRepository {
+	return &authorCacheInvalidator{AuthorRepository: authorRepository, books: books}
+}
+
+func (r *authorCacheInvalidator) UpdateAuthorById(ctx context.Context, id int, author domain.Author) (domain.Author, error) {
+	defer r.books.InvalidateAuthor(id)
+	return r.AuthorRepository.UpdateAuthorById(ctx, id, author)
+}
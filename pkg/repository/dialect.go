@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"strings"
+
+	"github.com/hoaibao/book-management/pkg/database/sqlbuilder"
+	"github.com/hoaibao/book-management/pkg/domain"
+)
+
+// dialect holds the SQL that differs between the databases the SQL
+// repositories run on. Everything else is written in the subset of SQL
+// PostgreSQL and SQLite share.
+type dialect struct {
+	// searchCondition matches the books selected as b whose name or
+	// authors contain every word of query.
+	searchCondition func(query string) sqlbuilder.Condition
+	// searchRank scores a book matched by searchCondition, higher for
+	// better matches. It reports false when query has nothing to rank by.
+	searchRank func(query string) (sqlbuilder.Expression, bool)
+}
+
+// postgresDialect searches the search_vector column added by migration 4.
+var postgresDialect = &dialect{
+	searchCondition: func(query string) sqlbuilder.Condition {
+		return sqlbuilder.Expr("b.search_vector @@ plainto_tsquery('english', ?)", query)
+	},
+	searchRank: func(query string) (sqlbuilder.Expression, bool) {
+		return sqlbuilder.Expr("ts_rank(b.search_vector, plainto_tsquery('english', ?))", query), true
+	},
+}
+
+// sqliteDialect searches the book_search full-text table, whose rowid is the
+// book id. bm25 is lower for better matches and weighs book names twice as
+// much as author names, like the weights of search_vector.
+var sqliteDialect = &dialect{
+	searchCondition: func(query string) sqlbuilder.Condition {
+		match, ok := ftsQuery(query)
+		if !ok {
+			return sqlbuilder.Expr("FALSE")
+		}
+		return sqlbuilder.Expr("b.id IN (SELECT rowid FROM book_search WHERE book_search MATCH ?)", match)
+	},
+	searchRank: func(query string) (sqlbuilder.Expression, bool) {
+		match, ok := ftsQuery(query)
+		if !ok {
+			return sqlbuilder.Expression{}, false
+		}
+		return sqlbuilder.Expr("-(SELECT bm25(book_search, 2.0, 1.0) FROM book_search WHERE book_search MATCH ? AND rowid = b.id)", match), true
+	},
+}
+
+// ftsQuery turns the terms of query into an FTS5 query matching all of
+// them, quoted so that no word is read as an FTS5 operator. Like
+// plainto_tsquery, punctuation and stop words are ignored, and the porter
+// tokenizer of book_search stems the terms the way the english
+// configuration does.
+func ftsQuery(query string) (string, bool) {
+	words := domain.SearchTerms(query)
+	if len(words) == 0 {
+		return "", false
+	}
+	for i, word := range words {
+		words[i] = `"` + word + `"`
+	}
+	return strings.Join(words, " "), true
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hoaibao/book-management/pkg/domain"
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// translateError turns driver errors into the domain errors the handlers
+// know how to report. Other errors are returned unchanged.
+func translateError(err error) error {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return translateSQLiteError(sqliteErr)
+	}
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+	switch pqErr.Code.Name() {
+	case "unique_violation":
+		if strings.Contains(pqErr.Constraint, "isbn") {
+			return fmt.Errorf("%w: %s", domain.ErrDuplicateISBN, pqErr.Detail)
+		}
+		return fmt.Errorf("%w: %s", domain.ErrConflict, pqErr.Message)
+	case "foreign_key_violation":
+		return fmt.Errorf("%w: %s", domain.ErrConflict, pqErr.Message)
+	case "invalid_text_representation", "numeric_value_out_of_range", "string_data_right_truncation":
+		return fmt.Errorf("%w: %s", domain.ErrValidation, pqErr.Message)
+	case "query_canceled":
+		// PostgreSQL cancels the statement when the request deadline or
+		// statement_timeout runs out.
+		return fmt.Errorf("%w: %s", context.DeadlineExceeded, pqErr.Message)
+	}
+	return err
+}
+
+// translateSQLiteError maps SQLite constraint errors like translateError
+// maps PostgreSQL ones. SQLite doesn't check column types and lengths.
+func translateSQLiteError(err *sqlite.Error) error {
+	message := sqlite.ErrorCodeString[err.Code()]
+	switch err.Code() {
+	case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+		if strings.Contains(err.Error(), "isbn") {
+			return fmt.Errorf("%w: %s", domain.ErrDuplicateISBN, err.Error())
+		}
+		return fmt.Errorf("%w: %s", domain.ErrConflict, err.Error())
+	case sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+		return fmt.Errorf("%w: %s", domain.ErrConflict, message)
+	case sqlite3.SQLITE_CONSTRAINT_NOTNULL, sqlite3.SQLITE_CONSTRAINT_CHECK:
+		return fmt.Errorf("%w: %s", domain.ErrValidation, message)
+	}
+	return err
+}
+
+func authorHasBooksError(authorId int) error {
+	return fmt.Errorf("%w: author %d still has books", domain.ErrConflict, authorId)
+}
+
+func duplicateAuthorError(name string, authorId int) error {
+	return fmt.Errorf("%w: author %d is already named %q", domain.ErrConflict, authorId, name)
+}
+
+func duplicateISBNError(isbn string, bookId int) error {
+	return fmt.Errorf("%w: book %d already has isbn %s", domain.ErrDuplicateISBN, bookId, isbn)
+}
+
+func versionMismatchError(bookId, version int) error {
+	return fmt.Errorf("%w: book %d is no longer at version %d", domain.ErrPreconditionFailed, bookId, version)
+}
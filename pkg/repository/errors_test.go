@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hoaibao/book-management/pkg/domain"
+	"github.com/lib/pq"
+)
+
+func TestTranslatePostgresError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *pq.Error
+		want error
+	}{
+		{name: "duplicate isbn", err: &pq.Error{Code: "23505", Constraint: "book_isbn_key"}, want: domain.ErrDuplicateISBN},
+		{name: "duplicate author", err: &pq.Error{Code: "23505", Constraint: "author_name_key"}, want: domain.ErrConflict},
+		{name: "foreign key", err: &pq.Error{Code: "23503"}, want: domain.ErrConflict},
+		{name: "value too long", err: &pq.Error{Code: "22001"}, want: domain.ErrValidation},
+		{name: "query canceled", err: &pq.Error{Code: "57014"}, want: context.DeadlineExceeded},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := translateError(test.err); !errors.Is(err, test.want) {
+				t.Errorf("translateError() = %v, want %v", err, test.want)
+			}
+		})
+	}
+
+	other := &pq.Error{Code: "42P01"}
+	if err := translateError(other); err != other {
+		t.Errorf("translateError() = %v, want the error unchanged", err)
+	}
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/hoaibao/book-management/pkg/domain"
+)
+
+type InMemoryAuthorRepository struct {
+	store *inMemoryStore
+}
+
+func NewInMemoryAuthorRepository() *InMemoryAuthorRepository {
+	return &InMemoryAuthorRepository{
+		store: newInMemoryStore(),
+	}
+}
+
+// InMemoryAuthorResolver is the in-memory counterpart of AuthorResolver.
+// The in-memory book repository writes a book and its new authors under the
+// lock of the store holding the authors, the way the SQL repositories share
+// a transaction.
+type InMemoryAuthorResolver interface {
+	AuthorRepository
+	authorStore() *inMemoryStore
+}
+
+func (r *InMemoryAuthorRepository) authorStore() *inMemoryStore {
+	return r.store
+}
+
+func (r *InMemoryAuthorRepository) GetAllAuthors(ctx context.Context) ([]domain.Author, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	result := make([]domain.Author, 0, len(r.store.authors))
+	for _, id := range sortedKeys(r.store.authors) {
+		result = append(result, r.store.authors[id])
+	}
+	return result, nil
+}
+
+func (r *InMemoryAuthorRepository) GetAuthorById(ctx context.Context, id int) (domain.Author, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Author{}, err
+	}
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	author, exist := r.store.authors[id]
+	if !exist {
+		return domain.Author{}, domain.NewNotFoundError("author", id)
+	}
+	return author, nil
+}
+
+func (r *InMemoryAuthorRepository) GetAuthorByName(ctx context.Context, name string) (domain.Author, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Author{}, err
+	}
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	if author, exist := r.store.findAuthorByName(name); exist {
+		return author, nil
+	}
+	return domain.Author{}, domain.NewNotFoundError("author", name)
+}
+
+func (r *InMemoryAuthorRepository) CreateAuthor(ctx context.Context, author domain.Author) (domain.Author, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Author{}, err
+	}
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if err := r.store.checkAuthorName(author.Name, 0); err != nil {
+		return domain.Author{}, err
+	}
+	return r.store.insertAuthor(author), nil
+}
+
+func (r *InMemoryAuthorRepository) UpdateAuthorById(ctx context.Context, id int, author domain.Author) (domain.Author, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Author{}, err
+	}
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exist := r.store.authors[id]; !exist {
+		return domain.Author{}, domain.NewNotFoundError("author", id)
+	}
+	if err := r.store.checkAuthorName(author.Name, id); err != nil {
+		return domain.Author{}, err
+	}
+	author.Id = id
+	r.store.authors[id] = author
+	return author, nil
+}
+
+func (r *InMemoryAuthorRepository) DeleteAuthorById(ctx context.Context, id int) (domain.Author, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Author{}, err
+	}
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	author, exist := r.store.authors[id]
+	if !exist {
+		return domain.Author{}, domain.NewNotFoundError("author", id)
+	}
+	if r.store.hasBookWithAuthor(id) {
+		return domain.Author{}, authorHasBooksError(id)
+	}
+	delete(r.store.authors, id)
+	return author, nil
+}
@@ -0,0 +1,430 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hoaibao/book-management/pkg/domain"
+)
+
+// InMemoryBookRepository keeps books in process memory. It mirrors the
+// behaviour of SQLBookRepository without needing a database.
+type InMemoryBookRepository struct {
+	store *inMemoryStore
+}
+
+// NewInMemoryBookRepository shares the store of authors, so authors
+// created through books are visible to both.
+func NewInMemoryBookRepository(authors InMemoryAuthorResolver) *InMemoryBookRepository {
+	return &InMemoryBookRepository{
+		store: authors.authorStore(),
+	}
+}
+
+func (r *InMemoryBookRepository) GetAllBooks(ctx context.Context, filter domain.BookFilter) ([]domain.Book, int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	checkYear := filter.From != "" && filter.To != ""
+	var from, to int
+	if checkYear {
+		var err error
+		if from, err = strconv.Atoi(filter.From); err != nil {
+			return nil, 0, domain.NewValidationError("from", "must be an integer")
+		}
+		if to, err = strconv.Atoi(filter.To); err != nil {
+			return nil, 0, domain.NewValidationError("to", "must be an integer")
+		}
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	terms := domain.SearchTerms(filter.Query)
+	result := make([]domain.Book, 0, len(r.store.books))
+	rank := make(map[int]int)
+	for _, id := range sortedKeys(r.store.books) {
+		book := r.store.withAuthors(r.store.books[id])
+		if filter.Query != "" {
+			if rank[id] = searchRank(book, terms); rank[id] == 0 {
+				continue
+			}
+		}
+		if filter.ISBN != "" && book.ISBN != filter.ISBN {
+			continue
+		}
+		if filter.Author != "" && !hasAuthor(book, filter.Author) {
+			continue
+		}
+		if checkYear && (book.PublishYear < from || book.PublishYear > to) {
+			continue
+		}
+		result = append(result, book)
+	}
+	if filter.Query != "" && len(filter.Sort) == 0 {
+		sort.SliceStable(result, func(i, j int) bool {
+			return rank[result[i].Id] > rank[result[j].Id]
+		})
+	}
+	if err := sortBooks(result, filter.Sort); err != nil {
+		return nil, 0, err
+	}
+	return paginate(result, filter.Limit, filter.Offset), len(result), nil
+}
+
+func (r *InMemoryBookRepository) GetBooksByAuthorId(ctx context.Context, authorId int) ([]domain.Book, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	result := make([]domain.Book, 0)
+	for _, id := range sortedKeys(r.store.books) {
+		book := r.store.withAuthors(r.store.books[id])
+		for _, author := range book.Authors {
+			if author.Id == authorId {
+				result = append(result, book)
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+func (r *InMemoryBookRepository) GetBookById(ctx context.Context, id int) (domain.Book, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Book{}, err
+	}
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	book, exist := r.store.books[id]
+	if !exist {
+		return domain.Book{}, domain.NewNotFoundError("book", id)
+	}
+	return r.store.withAuthors(book), nil
+}
+
+func (r *InMemoryBookRepository) CreateBook(ctx context.Context, book domain.Book, authors []string) (domain.Book, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Book{}, err
+	}
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if err := r.store.checkISBN(book.ISBN, 0); err != nil {
+		return domain.Book{}, err
+	}
+	r.store.lastBookId++
+	book.Id = r.store.lastBookId
+	book.Authors = nil
+	book.Version = 1
+	r.store.books[book.Id] = book
+	r.store.bookAuthors[book.Id] = r.store.linkAuthors(authors)
+	book = r.store.withAuthors(book)
+	r.store.recordRevision(ctx, domain.RevisionCreate, book)
+	return book, nil
+}
+
+func (r *InMemoryBookRepository) DeleteBookById(ctx context.Context, bookId int, version int) (domain.Book, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Book{}, err
+	}
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	book, exist := r.store.books[bookId]
+	if !exist {
+		return domain.Book{}, domain.NewNotFoundError("book", bookId)
+	}
+	if err := checkVersion(book, version); err != nil {
+		return domain.Book{}, err
+	}
+	deletedAt := time.Now().UTC()
+	book.DeletedAt = &deletedAt
+	book.Version++
+	delete(r.store.books, bookId)
+	r.store.deletedBooks[bookId] = book
+	book = r.store.withAuthors(book)
+	r.store.recordRevision(ctx, domain.RevisionDelete, book)
+	return book, nil
+}
+
+func (r *InMemoryBookRepository) GetDeletedBooks(ctx context.Context) ([]domain.Book, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	result := make([]domain.Book, 0, len(r.store.deletedBooks))
+	for _, id := range sortedKeys(r.store.deletedBooks) {
+		result = append(result, r.store.withAuthors(r.store.deletedBooks[id]))
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].DeletedAt.After(*result[j].DeletedAt)
+	})
+	return result, nil
+}
+
+func (r *InMemoryBookRepository) RestoreBookById(ctx context.Context, bookId int) (domain.Book, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Book{}, err
+	}
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	book, exist := r.store.deletedBooks[bookId]
+	if !exist {
+		return domain.Book{}, domain.NewNotFoundError("deleted book", bookId)
+	}
+	if err := r.store.checkISBN(book.ISBN, bookId); err != nil {
+		return domain.Book{}, err
+	}
+	book.DeletedAt = nil
+	book.Version++
+	delete(r.store.deletedBooks, bookId)
+	r.store.books[bookId] = book
+	book = r.store.withAuthors(book)
+	r.store.recordRevision(ctx, domain.RevisionRestore, book)
+	return book, nil
+}
+
+func (r *InMemoryBookRepository) PurgeDeletedBooks(ctx context.Context, before time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	purged := 0
+	for id, book := range r.store.deletedBooks {
+		if book.DeletedAt.After(before) {
+			continue
+		}
+		delete(r.store.deletedBooks, id)
+		delete(r.store.bookAuthors, id)
+		delete(r.store.revisions, id)
+		purged++
+	}
+	return purged, nil
+}
+
+func (r *InMemoryBookRepository) UpdateBookById(ctx context.Context, bookId int, bookData map[string][]string, version int) (domain.Book, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Book{}, err
+	}
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existBook, exist := r.store.books[bookId]
+	if !exist {
+		return domain.Book{}, domain.NewNotFoundError("book", bookId)
+	}
+	if err := checkVersion(existBook, version); err != nil {
+		return domain.Book{}, err
+	}
+
+	for key, value := range bookData {
+		if len(value) == 0 {
+			continue
+		}
+		switch key {
+		case "name":
+			existBook.Name = value[0]
+		case "isbn":
+			if err := r.store.checkISBN(value[0], bookId); err != nil {
+				return domain.Book{}, err
+			}
+			existBook.ISBN = value[0]
+		case "publishYear":
+			publishYearInt, err := strconv.Atoi(value[0])
+			if err != nil {
+				return domain.Book{}, domain.NewValidationError("publishYear", "must be an integer")
+			}
+			existBook.PublishYear = publishYearInt
+		}
+	}
+
+	existBook.Version++
+	r.store.books[bookId] = existBook
+	if authorArr := bookData["author"]; len(authorArr) > 0 {
+		r.store.bookAuthors[bookId] = r.store.linkAuthors(authorArr)
+	}
+	existBook = r.store.withAuthors(existBook)
+	r.store.recordRevision(ctx, domain.RevisionUpdate, existBook)
+	return existBook, nil
+}
+
+func (r *InMemoryBookRepository) RevertBookById(ctx context.Context, bookId int, snapshot domain.Book, version int) (domain.Book, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Book{}, err
+	}
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existBook, exist := r.store.books[bookId]
+	if !exist {
+		return domain.Book{}, domain.NewNotFoundError("book", bookId)
+	}
+	if err := checkVersion(existBook, version); err != nil {
+		return domain.Book{}, err
+	}
+	if err := r.store.checkISBN(snapshot.ISBN, bookId); err != nil {
+		return domain.Book{}, err
+	}
+
+	existBook.Name = snapshot.Name
+	existBook.ISBN = snapshot.ISBN
+	existBook.PublishYear = snapshot.PublishYear
+	existBook.Version++
+	r.store.books[bookId] = existBook
+	r.store.bookAuthors[bookId] = r.store.relinkAuthors(snapshot.Authors)
+	existBook = r.store.withAuthors(existBook)
+	r.store.recordRevision(ctx, domain.RevisionUpdate, existBook)
+	return existBook, nil
+}
+
+func (r *InMemoryBookRepository) GetBookHistory(ctx context.Context, bookId int) ([]domain.BookRevision, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	revisions, exist := r.store.revisions[bookId]
+	if !exist {
+		return nil, domain.NewNotFoundError("book", bookId)
+	}
+	return append([]domain.BookRevision(nil), revisions...), nil
+}
+
+func (r *InMemoryBookRepository) GetBookRevision(ctx context.Context, bookId, revision int) (domain.BookRevision, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.BookRevision{}, err
+	}
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	revisions := r.store.revisions[bookId]
+	if revision < 1 || revision > len(revisions) {
+		return domain.BookRevision{}, domain.NewNotFoundError("revision", revision)
+	}
+	return revisions[revision-1], nil
+}
+
+// sortBooks orders books the way the SQL repository does. Books are already
+// in id order, which a stable sort keeps as the last tie breaker.
+func sortBooks(books []domain.Book, sortFields []domain.SortField) error {
+	for _, sortField := range sortFields {
+		if _, exist := bookSortKeys[sortField.Field]; !exist {
+			return domain.NewValidationError("sort", "unknown field "+sortField.Field)
+		}
+	}
+	sort.SliceStable(books, func(i, j int) bool {
+		for _, sortField := range sortFields {
+			c := bookSortKeys[sortField.Field](books[i], books[j])
+			if c == 0 {
+				continue
+			}
+			if sortField.Desc {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+	return nil
+}
+
+var bookSortKeys = map[string]func(a, b domain.Book) int{
+	domain.SortById: func(a, b domain.Book) int {
+		return cmp.Compare(a.Id, b.Id)
+	},
+	domain.SortByName: func(a, b domain.Book) int {
+		return strings.Compare(a.Name, b.Name)
+	},
+	domain.SortByISBN: func(a, b domain.Book) int {
+		return strings.Compare(a.ISBN, b.ISBN)
+	},
+	domain.SortByPublishYear: func(a, b domain.Book) int {
+		return cmp.Compare(a.PublishYear, b.PublishYear)
+	},
+	domain.SortByAuthor: func(a, b domain.Book) int {
+		return strings.Compare(firstAuthorName(a), firstAuthorName(b))
+	},
+}
+
+func firstAuthorName(book domain.Book) string {
+	first := ""
+	for i, author := range book.Authors {
+		if i == 0 || author.Name < first {
+			first = author.Name
+		}
+	}
+	return first
+}
+
+// indexedWords returns the lowercase words of a book or author name.
+func indexedWords(name string) []string {
+	return domain.SearchWords(strings.ToLower(name))
+}
+
+// searchRank approximates the Postgres full-text search: every term must
+// start a word of the book name or of an author name, and matches in the
+// name weigh more than matches in author names. Zero means no match. Words
+// are not stemmed, so a prefix stands in for the stem: "program" finds
+// "programming", but "languages" doesn't find "language".
+func searchRank(book domain.Book, terms []string) int {
+	nameWords := indexedWords(book.Name)
+	var authorWords []string
+	for _, author := range book.Authors {
+		authorWords = append(authorWords, indexedWords(author.Name)...)
+	}
+
+	rank := 0
+	for _, term := range terms {
+		termRank := 2*countPrefixed(nameWords, term) + countPrefixed(authorWords, term)
+		if termRank == 0 {
+			return 0
+		}
+		rank += termRank
+	}
+	return rank
+}
+
+func countPrefixed(words []string, term string) int {
+	count := 0
+	for _, word := range words {
+		if strings.HasPrefix(word, term) {
+			count++
+		}
+	}
+	return count
+}
+
+func paginate(books []domain.Book, limit, offset int) []domain.Book {
+	if offset >= len(books) {
+		return []domain.Book{}
+	}
+	books = books[offset:]
+	if limit > 0 && limit < len(books) {
+		books = books[:limit]
+	}
+	return books
+}
+
+func hasAuthor(book domain.Book, name string) bool {
+	for _, author := range book.Authors {
+		if author.Name == name {
+			return true
+		}
+	}
+	return false
+}
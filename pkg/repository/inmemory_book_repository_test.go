@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hoaibao/book-management/pkg/domain"
+)
+
+func TestInMemorySearch(t *testing.T) {
+	repository := NewInMemoryBookRepository(NewInMemoryAuthorRepository())
+	for _, book := range []domain.Book{
+		newBook("The Go Programming Language", "9780134190440", 2015),
+		newBook("The C Programming Language", "9780131103627", 1988),
+		newBook("Structure and Interpretation of Computer Programs", "9780262510875", 1996),
+	} {
+		if _, err := repository.CreateBook(context.Background(), book, []string{"Brian Kernighan"}); err != nil {
+			t.Fatalf("CreateBook(%s) error = %v", book.Name, err)
+		}
+	}
+
+	// Unlike PostgreSQL and SQLite, the in-memory search matches the start
+	// of words rather than their stem.
+	tests := []struct {
+		query   string
+		wantIds []int
+	}{
+		{query: "programming language", wantIds: []int{1, 2}},
+		{query: "a programming language", wantIds: []int{1, 2}},
+		{query: "the", wantIds: []int{}},
+		{query: "program", wantIds: []int{1, 2, 3}},
+		{query: "languages", wantIds: []int{}},
+		{query: "kernighan go", wantIds: []int{1}},
+	}
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			books, total, err := repository.GetAllBooks(context.Background(), domain.BookFilter{Query: test.query})
+			if err != nil {
+				t.Fatalf("GetAllBooks() error = %v", err)
+			}
+			if ids := bookIds(books); !reflect.DeepEqual(ids, test.wantIds) || total != len(test.wantIds) {
+				t.Errorf("GetAllBooks() = %v, total %d, want %v", ids, total, test.wantIds)
+			}
+		})
+	}
+}
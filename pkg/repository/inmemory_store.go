@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hoaibao/book-management/pkg/domain"
+)
+
+// inMemoryStore holds the tables shared by the in-memory book and author
+// repositories behind a single lock, the way both SQL repositories share one
+// database.
+type inMemoryStore struct {
+	mu           sync.RWMutex
+	books        map[int]domain.Book
+	deletedBooks map[int]domain.Book
+	authors      map[int]domain.Author
+	bookAuthors  map[int][]int
+	revisions    map[int][]domain.BookRevision
+	lastBookId   int
+	lastAuthorId int
+}
+
+func newInMemoryStore() *inMemoryStore {
+	return &inMemoryStore{
+		books:        make(map[int]domain.Book),
+		deletedBooks: make(map[int]domain.Book),
+		authors:      make(map[int]domain.Author),
+		bookAuthors:  make(map[int][]int),
+		revisions:    make(map[int][]domain.BookRevision),
+	}
+}
+
+func (s *inMemoryStore) findAuthorByName(name string) (domain.Author, bool) {
+	// Lowest id wins, like the first row returned by the SQL lookup.
+	var found domain.Author
+	exist := false
+	for _, author := range s.authors {
+		if author.Name == name && (!exist || author.Id < found.Id) {
+			found = author
+			exist = true
+		}
+	}
+	return found, exist
+}
+
+func (s *inMemoryStore) insertAuthor(author domain.Author) domain.Author {
+	s.lastAuthorId++
+	author.Id = s.lastAuthorId
+	s.authors[author.Id] = author
+	return author
+}
+
+// linkAuthors returns the ids of the named authors, inserting the ones that
+// don't exist yet. A name given twice is linked once.
+func (s *inMemoryStore) linkAuthors(authors []string) []int {
+	authors = uniqueNames(authors)
+	authorIds := make([]int, 0, len(authors))
+	for _, authorName := range authors {
+		author, exist := s.findAuthorByName(authorName)
+		if !exist {
+			author = s.insertAuthor(domain.Author{Name: authorName})
+		}
+		authorIds = append(authorIds, author.Id)
+	}
+	return authorIds
+}
+
+// relinkAuthors returns the ids of the authors of a snapshot. The ones
+// deleted since the snapshot was taken are linked by name again.
+func (s *inMemoryStore) relinkAuthors(snapshot []domain.Author) []int {
+	authorIds := make([]int, 0, len(snapshot))
+	for _, author := range snapshot {
+		authorId := author.Id
+		if _, exist := s.authors[authorId]; !exist {
+			authorId = s.linkAuthors([]string{author.Name})[0]
+		}
+		if !slices.Contains(authorIds, authorId) {
+			authorIds = append(authorIds, authorId)
+		}
+	}
+	return authorIds
+}
+
+func (s *inMemoryStore) withAuthors(book domain.Book) domain.Book {
+	book.Authors = make([]domain.Author, 0, len(s.bookAuthors[book.Id]))
+	for _, authorId := range s.bookAuthors[book.Id] {
+		book.Authors = append(book.Authors, s.authors[authorId])
+	}
+	return book
+}
+
+func (s *inMemoryStore) hasBookWithAuthor(authorId int) bool {
+	for _, authorIds := range s.bookAuthors {
+		for _, id := range authorIds {
+			if id == authorId {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkAuthorName plays the part of the unique constraint on author.name.
+// The author being updated, if any, is skipped.
+func (s *inMemoryStore) checkAuthorName(name string, authorId int) error {
+	for id, author := range s.authors {
+		if id != authorId && author.Name == name {
+			return duplicateAuthorError(name, id)
+		}
+	}
+	return nil
+}
+
+// checkISBN plays the part of the unique constraint on book.isbn. The book
+// being updated, if any, is skipped.
+func (s *inMemoryStore) checkISBN(isbn string, bookId int) error {
+	for id, book := range s.books {
+		if id != bookId && book.ISBN == isbn {
+			return duplicateISBNError(isbn, id)
+		}
+	}
+	return nil
+}
+
+func (s *inMemoryStore) recordRevision(ctx context.Context, action string, book domain.Book) {
+	s.revisions[book.Id] = append(s.revisions[book.Id], domain.BookRevision{
+		BookId:    book.Id,
+		Revision:  len(s.revisions[book.Id]) + 1,
+		Action:    action,
+		Actor:     domain.ActorFromContext(ctx),
+		CreatedAt: time.Now().UTC(),
+		Book:      book,
+	})
+}
+
+// uniqueNames drops the repeated names, keeping the first of each.
+func uniqueNames(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	unique := make([]string, 0, len(names))
+	for _, name := range names {
+		if !seen[name] {
+			seen[name] = true
+			unique = append(unique, name)
+		}
+	}
+	return unique
+}
+
+func sortedKeys[V any](m map[int]V) []int {
+	ids := make([]int, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
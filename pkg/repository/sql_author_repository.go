@@ -0,0 +1,294 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/hoaibao/book-management/pkg/database"
+	"github.com/hoaibao/book-management/pkg/database/sqlbuilder"
+	"github.com/hoaibao/book-management/pkg/domain"
+	"github.com/hoaibao/book-management/pkg/logger"
+)
+
+// SQLAuthorRepository stores authors in a PostgreSQL or SQLite database,
+// which the book repository shares.
+type SQLAuthorRepository struct {
+	sqlLogger
+	DB *sql.DB
+}
+
+func NewSQLAuthorRepository(db *sql.DB, log logger.Logger) *SQLAuthorRepository {
+	return &SQLAuthorRepository{
+		sqlLogger: sqlLogger{logger: log},
+		DB:        db,
+	}
+}
+
+func selectAuthors() *sqlbuilder.SelectBuilder {
+	return authorTable.Select("id", "name", "birth_day").From("author")
+}
+
+func (authorRepository *SQLAuthorRepository) GetAuthorById(ctx context.Context, id int) (domain.Author, error) {
+	author, exist, err := authorRepository.queryAuthor(ctx, authorRepository.DB, selectAuthors().Where(sqlbuilder.Eq("id", id)))
+	if err != nil {
+		return domain.Author{}, err
+	}
+	if !exist {
+		return domain.Author{}, domain.NewNotFoundError("author", id)
+	}
+	return author, nil
+}
+
+func (authorRepository *SQLAuthorRepository) GetAuthorByName(ctx context.Context, name string) (domain.Author, error) {
+	author, exist, err := authorRepository.queryAuthor(ctx, authorRepository.DB, selectAuthors().Where(sqlbuilder.Eq("name", name)).OrderBy("id"))
+	if err != nil {
+		return domain.Author{}, err
+	}
+	if !exist {
+		return domain.Author{}, domain.NewNotFoundError("author", name)
+	}
+	return author, nil
+}
+
+func (authorRepository *SQLAuthorRepository) GetAllAuthors(ctx context.Context) ([]domain.Author, error) {
+	return authorRepository.queryAuthors(ctx, authorRepository.DB, selectAuthors().OrderBy("id"))
+}
+
+func (authorRepository *SQLAuthorRepository) CreateAuthor(ctx context.Context, author domain.Author) (domain.Author, error) {
+	sqlStatement, args, err := authorTable.
+		Insert("author", "name", "birth_day").
+		Values(author.Name, author.BirthDay).
+		Returning("id").
+		Build()
+	if err != nil {
+		authorRepository.checkError(ctx, err, "Can't build query")
+		return domain.Author{}, err
+	}
+	authorRepository.logStatement(ctx, sqlStatement, args)
+	err = authorRepository.DB.QueryRowContext(ctx, sqlStatement, args...).Scan(&author.Id)
+	if err != nil {
+		authorRepository.checkError(ctx, err, "Can't insert author")
+		return domain.Author{}, translateError(err)
+	}
+	authorRepository.logMessage(ctx, author)
+	return author, nil
+}
+
+func (authorRepository *SQLAuthorRepository) UpdateAuthorById(ctx context.Context, id int, author domain.Author) (domain.Author, error) {
+	rows, err := authorRepository.execStatement(ctx, authorRepository.DB,
+		authorTable.Update("author").
+			Set("name", author.Name).
+			Set("birth_day", author.BirthDay).
+			Where(sqlbuilder.Eq("id", id)),
+		"Can't update author")
+	if err != nil {
+		return domain.Author{}, err
+	}
+	if rows == 0 {
+		return domain.Author{}, domain.NewNotFoundError("author", id)
+	}
+	author.Id = id
+	authorRepository.logMessage(ctx, author)
+	return author, nil
+}
+
+// DeleteAuthorById refuses to delete authors that are still linked to a book.
+func (authorRepository *SQLAuthorRepository) DeleteAuthorById(ctx context.Context, id int) (domain.Author, error) {
+	var author domain.Author
+	err := database.WithTx(ctx, authorRepository.DB, func(tx *sql.Tx) error {
+		var exist bool
+		var err error
+		author, exist, err = authorRepository.queryAuthor(ctx, tx, selectAuthors().Where(sqlbuilder.Eq("id", id)))
+		if err != nil {
+			return err
+		}
+		if !exist {
+			return domain.NewNotFoundError("author", id)
+		}
+
+		sqlStatement, args, err := bookAuthorTable.Select().SelectExpr(sqlbuilder.Expr("COUNT(*)")).From("book_author").Where(sqlbuilder.Eq("author_id", id)).Build()
+		if err != nil {
+			authorRepository.checkError(ctx, err, "Can't build query")
+			return err
+		}
+		authorRepository.logStatement(ctx, sqlStatement, args)
+		var linkedBooks int
+		if err := tx.QueryRowContext(ctx, sqlStatement, args...).Scan(&linkedBooks); err != nil {
+			authorRepository.checkError(ctx, err, "Error while querying the database")
+			return translateError(err)
+		}
+		if linkedBooks > 0 {
+			return authorHasBooksError(id)
+		}
+
+		_, err = authorRepository.execStatement(ctx, tx, authorTable.Delete("author").Where(sqlbuilder.Eq("id", id)), "Can't delete author")
+		return err
+	})
+	if err != nil {
+		authorRepository.checkError(ctx, err, "Can't delete author")
+		return domain.Author{}, err
+	}
+	authorRepository.logMessage(ctx, author)
+	return author, nil
+}
+
+// ResolveAuthors returns the named authors, inserting the ones that don't
+// exist yet. Both go through tx, the transaction writing their book. A name
+// given twice is resolved once.
+func (authorRepository *SQLAuthorRepository) ResolveAuthors(ctx context.Context, tx *sql.Tx, authors []string) ([]domain.Author, error) {
+	authors = uniqueNames(authors)
+	if len(authors) == 0 {
+		return nil, nil
+	}
+	names := make([]interface{}, 0, len(authors))
+	for _, name := range authors {
+		names = append(names, name)
+	}
+	existing, err := authorRepository.queryAuthors(ctx, tx, selectAuthors().Where(sqlbuilder.In("name", names...)))
+	if err != nil {
+		return nil, err
+	}
+	authorsByName := make(map[string]domain.Author, len(authors))
+	for _, author := range existing {
+		authorsByName[author.Name] = author
+	}
+
+	var unKnowAuthor []string
+	for _, name := range authors {
+		if _, exist := authorsByName[name]; !exist {
+			unKnowAuthor = append(unKnowAuthor, name)
+		}
+	}
+	if len(unKnowAuthor) > 0 {
+		if err := authorRepository.insertAuthors(ctx, tx, unKnowAuthor, authorsByName); err != nil {
+			return nil, err
+		}
+	}
+
+	authorSlice := make([]domain.Author, 0, len(authors))
+	for _, name := range authors {
+		authorSlice = append(authorSlice, authorsByName[name])
+	}
+	return authorSlice, nil
+}
+
+// insertAuthors inserts the named authors and adds them to authorsByName.
+// Neither database promises to return the rows in the order of VALUES, so
+// each id is matched to its author by the name returned with it.
+func (authorRepository *SQLAuthorRepository) insertAuthors(ctx context.Context, tx *sql.Tx, unKnowAuthor []string, authorsByName map[string]domain.Author) error {
+	insertAuthor := authorTable.Insert("author", "name").Returning("id", "name")
+	for _, authorName := range unKnowAuthor {
+		insertAuthor.Values(authorName)
+	}
+	insertAuthorStatement, args, err := insertAuthor.Build()
+	if err != nil {
+		authorRepository.checkError(ctx, err, "Can't build query")
+		return err
+	}
+	authorRepository.logStatement(ctx, insertAuthorStatement, args)
+	rows, err := tx.QueryContext(ctx, insertAuthorStatement, args...)
+	if err != nil {
+		authorRepository.checkError(ctx, err, "Can't insert author")
+		return translateError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var author domain.Author
+		if err := rows.Scan(&author.Id, &author.Name); err != nil {
+			authorRepository.checkError(ctx, err, "Error while scanning row")
+			return err
+		}
+		authorsByName[author.Name] = author
+	}
+	return rows.Err()
+}
+
+// RelinkAuthors returns the authors of a snapshot as they are now, reading
+// through tx. The ones deleted since the snapshot was taken are resolved by
+// name again.
+func (authorRepository *SQLAuthorRepository) RelinkAuthors(ctx context.Context, tx *sql.Tx, snapshot []domain.Author) ([]domain.Author, error) {
+	ids := make([]interface{}, 0, len(snapshot))
+	for _, author := range snapshot {
+		ids = append(ids, author.Id)
+	}
+	existing, err := authorRepository.queryAuthors(ctx, tx, selectAuthors().Where(sqlbuilder.In("id", ids...)))
+	if err != nil {
+		return nil, err
+	}
+	authorsById := make(map[int]domain.Author, len(existing))
+	for _, author := range existing {
+		authorsById[author.Id] = author
+	}
+
+	var deleted []string
+	for _, author := range snapshot {
+		if _, exist := authorsById[author.Id]; !exist {
+			deleted = append(deleted, author.Name)
+		}
+	}
+	resolved, err := authorRepository.ResolveAuthors(ctx, tx, deleted)
+	if err != nil {
+		return nil, err
+	}
+	authorsByName := make(map[string]domain.Author, len(resolved))
+	for _, author := range resolved {
+		authorsByName[author.Name] = author
+	}
+
+	authors := make([]domain.Author, 0, len(snapshot))
+	linked := make(map[int]bool, len(snapshot))
+	for _, author := range snapshot {
+		current, exist := authorsById[author.Id]
+		if !exist {
+			current = authorsByName[author.Name]
+		}
+		if !linked[current.Id] {
+			linked[current.Id] = true
+			authors = append(authors, current)
+		}
+	}
+	return authors, nil
+}
+
+// queryAuthor returns the first author matched by query, run through db.
+func (authorRepository *SQLAuthorRepository) queryAuthor(ctx context.Context, db queryExecer, query *sqlbuilder.SelectBuilder) (domain.Author, bool, error) {
+	authors, err := authorRepository.queryAuthors(ctx, db, query)
+	if err != nil || len(authors) == 0 {
+		return domain.Author{}, false, err
+	}
+	return authors[0], true, nil
+}
+
+// queryAuthors runs a query built with selectAuthors through db, the pool or
+// the transaction writing a book.
+func (l sqlLogger) queryAuthors(ctx context.Context, db queryExecer, query *sqlbuilder.SelectBuilder) ([]domain.Author, error) {
+	sqlStatement, args, err := query.Build()
+	if err != nil {
+		l.checkError(ctx, err, "Can't build query")
+		return nil, err
+	}
+	l.logStatement(ctx, sqlStatement, args)
+	rows, err := db.QueryContext(ctx, sqlStatement, args...)
+	if err != nil {
+		l.checkError(ctx, err, "Error while querying the database")
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	authors := make([]domain.Author, 0)
+	for rows.Next() {
+		var author domain.Author
+		if err := rows.Scan(&author.Id, &author.Name, &author.BirthDay); err != nil {
+			l.checkError(ctx, err, "Error while scanning row")
+			return nil, err
+		}
+		l.logMessage(ctx, author)
+		authors = append(authors, author)
+	}
+	if err := rows.Err(); err != nil {
+		l.checkError(ctx, err, "Error while iterating rows")
+		return nil, err
+	}
+	return authors, nil
+}
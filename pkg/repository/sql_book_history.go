@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/hoaibao/book-management/pkg/database/sqlbuilder"
+	"github.com/hoaibao/book-management/pkg/domain"
+)
+
+var bookRevisionColumns = []string{"book_id", "revision", "action", "actor", "created_at", "snapshot"}
+
+// recordRevision stores a snapshot of book as its next revision. It runs in
+// the transaction of the change, whose row lock on the book keeps
+// concurrent changes from taking the same revision number.
+func (r *SQLBookRepository) recordRevision(ctx context.Context, db queryExecer, action string, book domain.Book) error {
+	sqlStatement, args, err := bookRevisionTable.
+		Select().
+		SelectExpr(sqlbuilder.Expr("COALESCE(MAX(revision), 0)")).
+		From("book_revision").
+		Where(sqlbuilder.Eq("book_id", book.Id)).
+		Build()
+	if err != nil {
+		r.checkError(ctx, err, "Can't build query")
+		return err
+	}
+	r.logStatement(ctx, sqlStatement, args)
+	var lastRevision int
+	if err := db.QueryRowContext(ctx, sqlStatement, args...).Scan(&lastRevision); err != nil {
+		r.checkError(ctx, err, "Error while querying the database")
+		return translateError(err)
+	}
+
+	snapshot, err := json.Marshal(book)
+	if err != nil {
+		return err
+	}
+	_, err = r.execStatement(ctx, db,
+		bookRevisionTable.Insert("book_revision", bookRevisionColumns...).
+			Values(book.Id, lastRevision+1, action, domain.ActorFromContext(ctx), time.Now().UTC(), snapshot),
+		"Error inserting book revision")
+	return err
+}
+
+func (r *SQLBookRepository) GetBookHistory(ctx context.Context, bookId int) ([]domain.BookRevision, error) {
+	revisions, err := r.queryRevisions(ctx, sqlbuilder.Eq("book_id", bookId))
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) == 0 {
+		return nil, domain.NewNotFoundError("book", bookId)
+	}
+	return revisions, nil
+}
+
+func (r *SQLBookRepository) GetBookRevision(ctx context.Context, bookId, revision int) (domain.BookRevision, error) {
+	revisions, err := r.queryRevisions(ctx, sqlbuilder.Eq("book_id", bookId), sqlbuilder.Eq("revision", revision))
+	if err != nil {
+		return domain.BookRevision{}, err
+	}
+	if len(revisions) == 0 {
+		return domain.BookRevision{}, domain.NewNotFoundError("revision", revision)
+	}
+	return revisions[0], nil
+}
+
+func (r *SQLBookRepository) queryRevisions(ctx context.Context, conditions ...sqlbuilder.Condition) ([]domain.BookRevision, error) {
+	sqlStatement, args, err := bookRevisionTable.
+		Select(bookRevisionColumns...).
+		From("book_revision").
+		Where(conditions...).
+		OrderBy("revision").
+		Build()
+	if err != nil {
+		r.checkError(ctx, err, "Can't build query")
+		return nil, err
+	}
+	r.logStatement(ctx, sqlStatement, args)
+	rows, err := r.DB.QueryContext(ctx, sqlStatement, args...)
+	if err != nil {
+		r.checkError(ctx, err, "Error while querying the database")
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	revisions := make([]domain.BookRevision, 0)
+	for rows.Next() {
+		var revision domain.BookRevision
+		var snapshot []byte
+		err := rows.Scan(&revision.BookId, &revision.Revision, &revision.Action, &revision.Actor, &revision.CreatedAt, &snapshot)
+		if err != nil {
+			r.checkError(ctx, err, "Error while scanning row")
+			return nil, err
+		}
+		if err := json.Unmarshal(snapshot, &revision.Book); err != nil {
+			r.checkError(ctx, err, "Can't decode book snapshot")
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+	if err := rows.Err(); err != nil {
+		r.checkError(ctx, err, "Error while iterating rows")
+		return nil, err
+	}
+	return revisions, nil
+}
@@ -0,0 +1,535 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/hoaibao/book-management/pkg/database"
+	"github.com/hoaibao/book-management/pkg/database/sqlbuilder"
+	"github.com/hoaibao/book-management/pkg/domain"
+	"github.com/hoaibao/book-management/pkg/logger"
+)
+
+// authorNameSortKey is the first author name of the book selected as b.
+var authorNameSortKey = sqlbuilder.Expr("(SELECT MIN(sa.name) FROM book_author sba JOIN author sa ON sba.author_id = sa.id WHERE sba.book_id = b.id)")
+
+// Each table has its own whitelist, with its columns both bare and
+// qualified by the alias the queries give the table.
+var (
+	bookTable = sqlbuilder.NewWhitelist(
+		"id", "isbn", "name", "publish_year", "deleted_at", "version",
+		"b.id", "b.isbn", "b.name", "b.publish_year", "b.deleted_at", "b.version",
+	)
+	authorTable = sqlbuilder.NewWhitelist(
+		"id", "name", "birth_day",
+		"a.id", "a.name", "a.birth_day",
+	)
+	bookAuthorTable = sqlbuilder.NewWhitelist(
+		"book_id", "author_id",
+		"ba.book_id", "ba.author_id",
+	)
+	bookRevisionTable = sqlbuilder.NewWhitelist(bookRevisionColumns...)
+	// booksWithAuthors is the join of the three tables above.
+	booksWithAuthors = bookTable.Merge(bookAuthorTable, authorTable)
+)
+
+var (
+	bookAuthorColumns = []string{
+		"b.id", "b.isbn", "b.name", "b.publish_year", "b.deleted_at", "b.version",
+		"a.id", "a.name", "a.birth_day",
+	}
+	// bookSortColumns are the sort fields on a column. Books are sorted by
+	// author with authorNameSortKey.
+	bookSortColumns = map[string]string{
+		domain.SortById:          "b.id",
+		domain.SortByName:        "b.name",
+		domain.SortByISBN:        "b.isbn",
+		domain.SortByPublishYear: "b.publish_year",
+	}
+	bookUpdateColumns = map[string]string{
+		"name":        "name",
+		"isbn":        "isbn",
+		"publishYear": "publish_year",
+	}
+
+	activeBook  = sqlbuilder.Expr("b.deleted_at IS NULL")
+	deletedBook = sqlbuilder.Expr("b.deleted_at IS NOT NULL")
+)
+
+// queryExecer is implemented by both *sql.DB and *sql.Tx.
+type queryExecer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// SQLBookRepository stores books in PostgreSQL, or in SQLite when created
+// with NewSQLiteBookRepository.
+type SQLBookRepository struct {
+	sqlLogger
+	DB      *sql.DB
+	authors AuthorResolver
+	dialect *dialect
+}
+
+// NewPostgresBookRepository stores books in the PostgreSQL database db.
+// authors must use the same database.
+func NewPostgresBookRepository(db *sql.DB, authors AuthorResolver, log logger.Logger) *SQLBookRepository {
+	return &SQLBookRepository{
+		sqlLogger: sqlLogger{logger: log},
+		DB:        db,
+		authors:   authors,
+		dialect:   postgresDialect,
+	}
+}
+
+// NewSQLiteBookRepository stores books in the SQLite database db.
+// authors must use the same database.
+func NewSQLiteBookRepository(db *sql.DB, authors AuthorResolver, log logger.Logger) *SQLBookRepository {
+	return &SQLBookRepository{
+		sqlLogger: sqlLogger{logger: log},
+		DB:        db,
+		authors:   authors,
+		dialect:   sqliteDialect,
+	}
+}
+
+func selectBooksWithAuthors() *sqlbuilder.SelectBuilder {
+	return booksWithAuthors.Select(bookAuthorColumns...).
+		From("book b").
+		Join("book_author ba ON b.id = ba.book_id").
+		Join("author a ON ba.author_id = a.id")
+}
+
+// scanBookAuthor reads one row selected with bookAuthorColumns.
+func scanBookAuthor(rows *sql.Rows) (domain.Book, domain.Author, error) {
+	var book domain.Book
+	var author domain.Author
+	err := rows.Scan(&book.Id, &book.ISBN, &book.Name, &book.PublishYear, &book.DeletedAt, &book.Version, &author.Id, &author.Name, &author.BirthDay)
+	return book, author, err
+}
+
+// queryBooks runs a query selecting bookAuthorColumns and folds the
+// book x author rows into books, keeping the order of the first row of each.
+func (r *SQLBookRepository) queryBooks(ctx context.Context, query *sqlbuilder.SelectBuilder) ([]domain.Book, error) {
+	sqlStatement, args, err := query.Build()
+	if err != nil {
+		r.checkError(ctx, err, "Can't build query")
+		return nil, err
+	}
+
+	r.logStatement(ctx, sqlStatement, args)
+	rows, err := r.DB.QueryContext(ctx, sqlStatement, args...)
+	if err != nil {
+		r.checkError(ctx, err, "Error while querying the database")
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	result := make([]domain.Book, 0)
+	position := make(map[int]int)
+	for rows.Next() {
+		book, author, err := scanBookAuthor(rows)
+		if err != nil {
+			r.checkError(ctx, err, "Error while scanning row")
+			return nil, err
+		}
+		r.logMessage(ctx, book)
+		if index, isExistBook := position[book.Id]; isExistBook {
+			result[index].Authors = append(result[index].Authors, author)
+		} else {
+			book.Authors = append(book.Authors, author)
+			position[book.Id] = len(result)
+			result = append(result, book)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		r.checkError(ctx, err, "Error while iterating rows")
+		return nil, err
+	}
+	return result, nil
+}
+
+// bookConditions translates filter into conditions on the book table, so
+// that pages and totals count books rather than book x author rows.
+func (r *SQLBookRepository) bookConditions(filter domain.BookFilter) []sqlbuilder.Condition {
+	conditions := []sqlbuilder.Condition{activeBook}
+	if filter.ISBN != "" {
+		conditions = append(conditions, sqlbuilder.Eq("b.isbn", filter.ISBN))
+	}
+	if filter.Author != "" {
+		booksOfAuthor := booksWithAuthors.Select("ba.book_id").
+			From("book_author ba").
+			Join("author a ON ba.author_id = a.id").
+			Where(sqlbuilder.Eq("a.name", filter.Author))
+		conditions = append(conditions, sqlbuilder.InSelect("b.id", booksOfAuthor))
+	}
+	if filter.From != "" && filter.To != "" {
+		conditions = append(conditions, sqlbuilder.Gte("b.publish_year", filter.From), sqlbuilder.Lte("b.publish_year", filter.To))
+	}
+	if filter.Query != "" {
+		conditions = append(conditions, r.dialect.searchCondition(filter.Query))
+	}
+	return conditions
+}
+
+// orderBooks sorts query by the sort of filter, or by relevance when
+// searching without a sort, and finally by the book id so pages are stable.
+func (r *SQLBookRepository) orderBooks(query *sqlbuilder.SelectBuilder, filter domain.BookFilter) error {
+	if filter.Query != "" && len(filter.Sort) == 0 {
+		if rank, ok := r.dialect.searchRank(filter.Query); ok {
+			query.OrderByExpr(rank, true)
+		}
+	}
+	for _, sortField := range filter.Sort {
+		if sortField.Field == domain.SortByAuthor {
+			query.OrderByExpr(authorNameSortKey, sortField.Desc)
+			continue
+		}
+		column, exist := bookSortColumns[sortField.Field]
+		if !exist {
+			return domain.NewValidationError("sort", "unknown field "+sortField.Field)
+		}
+		if sortField.Desc {
+			column = "-" + column
+		}
+		query.OrderBy(column)
+	}
+	query.OrderBy("b.id")
+	return nil
+}
+
+func (r *SQLBookRepository) GetAllBooks(ctx context.Context, filter domain.BookFilter) ([]domain.Book, int, error) {
+	conditions := r.bookConditions(filter)
+
+	countStatement, args, err := bookTable.Select().SelectExpr(sqlbuilder.Expr("COUNT(*)")).From("book b").Where(conditions...).Build()
+	if err != nil {
+		r.checkError(ctx, err, "Can't build query")
+		return nil, 0, err
+	}
+	r.logStatement(ctx, countStatement, args)
+	var total int
+	if err := r.DB.QueryRowContext(ctx, countStatement, args...).Scan(&total); err != nil {
+		r.checkError(ctx, err, "Error while counting books")
+		return nil, 0, translateError(err)
+	}
+
+	page := bookTable.Select("b.id").
+		From("book b").
+		Where(conditions...).
+		Limit(filter.Limit).
+		Offset(filter.Offset)
+	if err := r.orderBooks(page, filter); err != nil {
+		return nil, 0, err
+	}
+	books := selectBooksWithAuthors().Where(sqlbuilder.InSelect("b.id", page))
+	if err := r.orderBooks(books, filter); err != nil {
+		return nil, 0, err
+	}
+	result, err := r.queryBooks(ctx, books.OrderBy("a.id"))
+	if err != nil {
+		return nil, 0, err
+	}
+	return result, total, nil
+}
+
+func (r *SQLBookRepository) GetBooksByAuthorId(ctx context.Context, authorId int) ([]domain.Book, error) {
+	linkedBooks := bookAuthorTable.Select("book_id").From("book_author").Where(sqlbuilder.Eq("author_id", authorId))
+	return r.queryBooks(ctx, selectBooksWithAuthors().Where(activeBook, sqlbuilder.InSelect("b.id", linkedBooks)).OrderBy("b.id"))
+}
+
+func (r *SQLBookRepository) GetBookById(ctx context.Context, id int) (domain.Book, error) {
+	return r.queryBook(ctx, id, activeBook)
+}
+
+// queryBook reads the book with the given id if it matches condition.
+func (r *SQLBookRepository) queryBook(ctx context.Context, id int, condition sqlbuilder.Condition) (domain.Book, error) {
+	books, err := r.queryBooks(ctx, selectBooksWithAuthors().Where(sqlbuilder.Eq("b.id", id), condition).OrderBy("a.id"))
+	if err != nil {
+		return domain.Book{}, err
+	}
+	if len(books) == 0 {
+		return domain.Book{}, domain.NewNotFoundError("book", id)
+	}
+	return books[0], nil
+}
+
+// checkVersion compares the version a client expects with the book, a zero
+// version matching any.
+func checkVersion(book domain.Book, version int) error {
+	if version != 0 && version != book.Version {
+		return versionMismatchError(book.Id, version)
+	}
+	return nil
+}
+
+func (r *SQLBookRepository) linkAuthors(ctx context.Context, db queryExecer, bookId int, authors []domain.Author) (int64, error) {
+	if len(authors) == 0 {
+		return 0, nil
+	}
+	insertBookAuthor := bookAuthorTable.Insert("book_author", "book_id", "author_id")
+	for _, author := range authors {
+		insertBookAuthor.Values(bookId, author.Id)
+	}
+	return r.execStatement(ctx, db, insertBookAuthor, "Error inserting associations")
+}
+
+func (r *SQLBookRepository) CreateBook(ctx context.Context, book domain.Book, authors []string) (domain.Book, error) {
+	err := database.WithTx(ctx, r.DB, func(tx *sql.Tx) error {
+		authorSlice, err := r.authors.ResolveAuthors(ctx, tx, authors)
+		if err != nil {
+			return err
+		}
+
+		sqlStatement, args, err := bookTable.
+			Insert("book", "isbn", "name", "publish_year").
+			Values(book.ISBN, book.Name, book.PublishYear).
+			Returning("id").
+			Build()
+		if err != nil {
+			r.checkError(ctx, err, "Can't build query")
+			return err
+		}
+		r.logStatement(ctx, sqlStatement, args)
+		err = tx.QueryRowContext(ctx, sqlStatement, args...).Scan(&book.Id)
+		if err != nil {
+			r.checkError(ctx, err, "Can't insert database")
+			return translateError(err)
+		}
+
+		numberOfRowsAffected, err := r.linkAuthors(ctx, tx, book.Id, authorSlice)
+		if err != nil {
+			return err
+		}
+		r.logMessage(ctx, "Number of rows affected:", numberOfRowsAffected)
+		book.Authors = authorSlice
+		book.Version = 1
+		return r.recordRevision(ctx, tx, domain.RevisionCreate, book)
+	})
+	if err != nil {
+		r.checkError(ctx, err, "Can't create book")
+		return domain.Book{}, err
+	}
+	r.logMessage(ctx, book)
+	return book, nil
+}
+
+func (r *SQLBookRepository) DeleteBookById(ctx context.Context, bookId int, version int) (domain.Book, error) {
+	book, err := r.GetBookById(ctx, bookId)
+	if err != nil {
+		return domain.Book{}, err
+	}
+	if err := checkVersion(book, version); err != nil {
+		return domain.Book{}, err
+	}
+
+	deletedAt := time.Now().UTC()
+	book.DeletedAt = &deletedAt
+	book.Version++
+	err = database.WithTx(ctx, r.DB, func(tx *sql.Tx) error {
+		rows, err := r.execStatement(ctx, tx,
+			bookTable.Update("book AS b").
+				Set("deleted_at", deletedAt).
+				Set("version", book.Version).
+				Where(sqlbuilder.Eq("b.id", bookId), sqlbuilder.Eq("b.version", book.Version-1), activeBook),
+			"Error deleting book")
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return versionMismatchError(bookId, book.Version-1)
+		}
+		return r.recordRevision(ctx, tx, domain.RevisionDelete, book)
+	})
+	if err != nil {
+		r.checkError(ctx, err, "Can't delete book")
+		return domain.Book{}, err
+	}
+
+	r.logMessage(ctx, book)
+	return book, nil
+}
+
+func (r *SQLBookRepository) GetDeletedBooks(ctx context.Context) ([]domain.Book, error) {
+	return r.queryBooks(ctx, selectBooksWithAuthors().Where(deletedBook).OrderBy("-b.deleted_at", "b.id", "a.id"))
+}
+
+func (r *SQLBookRepository) RestoreBookById(ctx context.Context, bookId int) (domain.Book, error) {
+	book, err := r.queryBook(ctx, bookId, deletedBook)
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.Book{}, domain.NewNotFoundError("deleted book", bookId)
+	}
+	if err != nil {
+		return domain.Book{}, err
+	}
+
+	book.DeletedAt = nil
+	book.Version++
+	err = database.WithTx(ctx, r.DB, func(tx *sql.Tx) error {
+		rows, err := r.execStatement(ctx, tx,
+			bookTable.Update("book AS b").
+				Set("deleted_at", nil).
+				Set("version", book.Version).
+				Where(sqlbuilder.Eq("b.id", bookId), sqlbuilder.Eq("b.version", book.Version-1), deletedBook),
+			"Error restoring book")
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return domain.NewNotFoundError("deleted book", bookId)
+		}
+		return r.recordRevision(ctx, tx, domain.RevisionRestore, book)
+	})
+	if err != nil {
+		r.checkError(ctx, err, "Can't restore book")
+		return domain.Book{}, err
+	}
+
+	r.logMessage(ctx, book)
+	return book, nil
+}
+
+func (r *SQLBookRepository) PurgeDeletedBooks(ctx context.Context, before time.Time) (int, error) {
+	// SQLite compares times as text, which only works in a single time zone.
+	before = before.UTC()
+	expiredBooks := bookTable.Select("b.id").From("book b").Where(deletedBook, sqlbuilder.Lte("b.deleted_at", before))
+
+	var purged int64
+	err := database.WithTx(ctx, r.DB, func(tx *sql.Tx) error {
+		_, err := r.execStatement(ctx, tx,
+			bookAuthorTable.Delete("book_author").Where(sqlbuilder.InSelect("book_id", expiredBooks)),
+			"Error deleting book_author")
+		if err != nil {
+			return err
+		}
+
+		_, err = r.execStatement(ctx, tx,
+			bookRevisionTable.Delete("book_revision").Where(sqlbuilder.InSelect("book_id", expiredBooks)),
+			"Error deleting book_revision")
+		if err != nil {
+			return err
+		}
+
+		purged, err = r.execStatement(ctx, tx,
+			bookTable.Delete("book AS b").Where(deletedBook, sqlbuilder.Lte("b.deleted_at", before)),
+			"Error deleting book")
+		return err
+	})
+	if err != nil {
+		r.checkError(ctx, err, "Can't purge deleted books")
+		return 0, err
+	}
+	r.logMessage(ctx, "Number of books purged:", purged)
+	return int(purged), nil
+}
+
+func (r *SQLBookRepository) UpdateBookById(ctx context.Context, bookId int, bookData map[string][]string, version int) (domain.Book, error) {
+	var linkAuthors func(tx *sql.Tx) ([]domain.Author, error)
+	if authorArr := bookData["author"]; len(authorArr) > 0 {
+		linkAuthors = func(tx *sql.Tx) ([]domain.Author, error) {
+			return r.authors.ResolveAuthors(ctx, tx, authorArr)
+		}
+	}
+	return r.updateBook(ctx, bookId, bookData, version, linkAuthors)
+}
+
+func (r *SQLBookRepository) RevertBookById(ctx context.Context, bookId int, snapshot domain.Book, version int) (domain.Book, error) {
+	bookData := map[string][]string{
+		"name":        {snapshot.Name},
+		"isbn":        {snapshot.ISBN},
+		"publishYear": {strconv.Itoa(snapshot.PublishYear)},
+	}
+	return r.updateBook(ctx, bookId, bookData, version, func(tx *sql.Tx) ([]domain.Author, error) {
+		return r.authors.RelinkAuthors(ctx, tx, snapshot.Authors)
+	})
+}
+
+// updateBook sets the columns named in bookData. When linkAuthors is not
+// nil, the authors it returns replace the ones of the book in the same
+// transaction.
+func (r *SQLBookRepository) updateBook(ctx context.Context, bookId int, bookData map[string][]string, version int, linkAuthors func(tx *sql.Tx) ([]domain.Author, error)) (domain.Book, error) {
+	existBook, err := r.GetBookById(ctx, bookId)
+	if err != nil {
+		return domain.Book{}, err
+	}
+	r.logMessage(ctx, existBook)
+	if err := checkVersion(existBook, version); err != nil {
+		return domain.Book{}, err
+	}
+
+	// The version is bumped even when only the authors change, which also
+	// locks the book row for the rest of the transaction.
+	updateBook := bookTable.Update("book AS b").
+		Set("version", existBook.Version+1).
+		Where(sqlbuilder.Eq("b.id", bookId), sqlbuilder.Eq("b.version", existBook.Version), activeBook)
+
+	for key, value := range bookData {
+		column, isKnownColumn := bookUpdateColumns[key]
+		if !isKnownColumn || len(value) == 0 {
+			continue
+		}
+
+		switch key {
+		case "name":
+			existBook.Name = value[0]
+			updateBook.Set(column, value[0])
+		case "isbn":
+			existBook.ISBN = value[0]
+			updateBook.Set(column, value[0])
+		case "publishYear":
+			publishYearInt, err := strconv.Atoi(value[0])
+			if err != nil {
+				return domain.Book{}, domain.NewValidationError("publishYear", "must be an integer")
+			}
+			existBook.PublishYear = publishYearInt
+			updateBook.Set(column, publishYearInt)
+		}
+	}
+
+	var totalRows int64
+	err = database.WithTx(ctx, r.DB, func(tx *sql.Tx) error {
+		rows, err := r.execStatement(ctx, tx, updateBook, "Can't update database")
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return versionMismatchError(bookId, existBook.Version)
+		}
+		totalRows += rows
+		existBook.Version++
+
+		if linkAuthors != nil {
+			authorObjSlice, err := linkAuthors(tx)
+			if err != nil {
+				return err
+			}
+
+			rows, err := r.execStatement(ctx, tx,
+				bookAuthorTable.Delete("book_author").Where(sqlbuilder.Eq("book_id", bookId)),
+				"Error deleting existing associations")
+			if err != nil {
+				return err
+			}
+			totalRows += rows
+
+			rows, err = r.linkAuthors(ctx, tx, bookId, authorObjSlice)
+			if err != nil {
+				return err
+			}
+			totalRows += rows
+			existBook.Authors = authorObjSlice
+		}
+		return r.recordRevision(ctx, tx, domain.RevisionUpdate, existBook)
+	})
+	if err != nil {
+		r.checkError(ctx, err, "Can't update book")
+		return domain.Book{}, err
+	}
+
+	r.logMessage(ctx, "Number of rows affected:", totalRows)
+	return existBook, nil
+}
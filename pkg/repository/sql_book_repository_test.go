@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hoaibao/book-management/pkg/database"
+	"github.com/hoaibao/book-management/pkg/database/migration"
+	"github.com/hoaibao/book-management/pkg/domain"
+	"github.com/hoaibao/book-management/pkg/logger"
+)
+
+// newSQLiteBookRepository returns a repository on a new, migrated SQLite
+// database holding the given books, created in order.
+func newSQLiteBookRepository(t *testing.T, books ...domain.Book) *SQLBookRepository {
+	t.Helper()
+	db, err := database.NewSQLiteConnection(filepath.Join(t.TempDir(), "books.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	migrator, err := migration.NewSQLiteMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	log := logger.NewNop()
+	repository := NewSQLiteBookRepository(db, NewSQLAuthorRepository(db, log), log)
+	for _, book := range books {
+		var authors []string
+		for _, author := range book.Authors {
+			authors = append(authors, author.Name)
+		}
+		if _, err := repository.CreateBook(context.Background(), book, authors); err != nil {
+			t.Fatalf("CreateBook(%s) error = %v", book.Name, err)
+		}
+	}
+	return repository
+}
+
+func newBook(name, isbn string, publishYear int, authors ...string) domain.Book {
+	book := domain.Book{Name: name, ISBN: isbn, PublishYear: publishYear}
+	for _, author := range authors {
+		book.Authors = append(book.Authors, domain.Author{Name: author})
+	}
+	return book
+}
+
+func bookIds(books []domain.Book) []int {
+	ids := []int{}
+	for _, book := range books {
+		ids = append(ids, book.Id)
+	}
+	return ids
+}
+
+func TestSQLiteGetAllBooks(t *testing.T) {
+	repository := newSQLiteBookRepository(t,
+		newBook("The Go Programming Language", "9780134190440", 2015, "Alan Donovan", "Brian Kernighan"),
+		newBook("The C Programming Language", "9780131103627", 1988, "Brian Kernighan", "Dennis Ritchie"),
+		newBook("Structure and Interpretation of Computer Programs", "9780262510875", 1996, "Harold Abelson"),
+	)
+
+	tests := []struct {
+		name      string
+		filter    domain.BookFilter
+		wantIds   []int
+		wantTotal int
+	}{
+		{name: "all", wantIds: []int{1, 2, 3}, wantTotal: 3},
+		{name: "page", filter: domain.BookFilter{Limit: 1, Offset: 1}, wantIds: []int{2}, wantTotal: 3},
+		{name: "isbn", filter: domain.BookFilter{ISBN: "9780131103627"}, wantIds: []int{2}, wantTotal: 1},
+		{name: "author", filter: domain.BookFilter{Author: "Brian Kernighan"}, wantIds: []int{1, 2}, wantTotal: 2},
+		{name: "publish years", filter: domain.BookFilter{From: "1990", To: "2020"}, wantIds: []int{1, 3}, wantTotal: 2},
+		{
+			name:    "sort by publish year",
+			filter:  domain.BookFilter{Sort: []domain.SortField{{Field: domain.SortByPublishYear, Desc: true}}},
+			wantIds: []int{1, 3, 2}, wantTotal: 3,
+		},
+		{
+			name:    "sort by first author",
+			filter:  domain.BookFilter{Sort: []domain.SortField{{Field: domain.SortByAuthor, Desc: true}}},
+			wantIds: []int{3, 2, 1}, wantTotal: 3,
+		},
+		{name: "search a book name", filter: domain.BookFilter{Query: "programming language"}, wantIds: []int{1, 2}, wantTotal: 2},
+		{name: "search an author name", filter: domain.BookFilter{Query: "ritchie"}, wantIds: []int{2}, wantTotal: 1},
+		{name: "search with punctuation", filter: domain.BookFilter{Query: `"go" OR c*`}, wantIds: []int{}, wantTotal: 0},
+		{name: "search without words", filter: domain.BookFilter{Query: "*"}, wantIds: []int{}, wantTotal: 0},
+		{name: "search skips stop words", filter: domain.BookFilter{Query: "a programming language"}, wantIds: []int{1, 2}, wantTotal: 2},
+		{name: "search stop words only", filter: domain.BookFilter{Query: "the"}, wantIds: []int{}, wantTotal: 0},
+		{name: "search stems words", filter: domain.BookFilter{Query: "languages"}, wantIds: []int{1, 2}, wantTotal: 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			books, total, err := repository.GetAllBooks(context.Background(), test.filter)
+			if err != nil {
+				t.Fatalf("GetAllBooks() error = %v", err)
+			}
+			if ids := bookIds(books); !reflect.DeepEqual(ids, test.wantIds) || total != test.wantTotal {
+				t.Errorf("GetAllBooks() = %v of %d, want %v of %d", ids, total, test.wantIds, test.wantTotal)
+			}
+		})
+	}
+}
+
+func TestSQLiteBookLifecycle(t *testing.T) {
+	ctx := context.Background()
+	repository := newSQLiteBookRepository(t, newBook("The Go Programming Language", "9780134190440", 2015, "Alan Donovan"))
+
+	book, err := repository.GetBookById(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetBookById() error = %v", err)
+	}
+	if book.Version != 1 || len(book.Authors) != 1 || book.Authors[0].Name != "Alan Donovan" {
+		t.Errorf("GetBookById() = %+v", book)
+	}
+
+	if _, err := repository.CreateBook(ctx, newBook("Copy", "9780134190440", 2015, "Alan Donovan"), []string{"Alan Donovan"}); !errors.Is(err, domain.ErrDuplicateISBN) {
+		t.Errorf("CreateBook() with a used isbn error = %v, want %v", err, domain.ErrDuplicateISBN)
+	}
+
+	if _, err := repository.UpdateBookById(ctx, 1, map[string][]string{"name": {"Go"}}, 2); !errors.Is(err, domain.ErrPreconditionFailed) {
+		t.Errorf("UpdateBookById() with a stale version error = %v, want %v", err, domain.ErrPreconditionFailed)
+	}
+	book, err = repository.UpdateBookById(ctx, 1, map[string][]string{"name": {"Go"}, "author": {"Alan Donovan", "Brian Kernighan"}}, 1)
+	if err != nil {
+		t.Fatalf("UpdateBookById() error = %v", err)
+	}
+	if book.Name != "Go" || book.Version != 2 || len(book.Authors) != 2 {
+		t.Errorf("UpdateBookById() = %+v", book)
+	}
+
+	if _, err := repository.DeleteBookById(ctx, 1, 2); err != nil {
+		t.Fatalf("DeleteBookById() error = %v", err)
+	}
+	if _, err := repository.GetBookById(ctx, 1); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("GetBookById() of a deleted book error = %v, want %v", err, domain.ErrNotFound)
+	}
+	if deleted, err := repository.GetDeletedBooks(ctx); err != nil || !reflect.DeepEqual(bookIds(deleted), []int{1}) {
+		t.Errorf("GetDeletedBooks() = %v, %v, want book 1", bookIds(deleted), err)
+	}
+	if _, err := repository.RestoreBookById(ctx, 1); err != nil {
+		t.Fatalf("RestoreBookById() error = %v", err)
+	}
+
+	history, err := repository.GetBookHistory(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetBookHistory() error = %v", err)
+	}
+	var actions []string
+	for _, revision := range history {
+		actions = append(actions, revision.Action)
+	}
+	want := []string{domain.RevisionCreate, domain.RevisionUpdate, domain.RevisionDelete, domain.RevisionRestore}
+	if !reflect.DeepEqual(actions, want) {
+		t.Errorf("GetBookHistory() actions = %v, want %v", actions, want)
+	}
+}
+
+func TestSQLiteDeleteAuthorWithBooks(t *testing.T) {
+	repository := newSQLiteBookRepository(t, newBook("The Go Programming Language", "9780134190440", 2015, "Alan Donovan"))
+	authorRepository := NewSQLAuthorRepository(repository.DB, logger.NewNop())
+	if _, err := authorRepository.DeleteAuthorById(context.Background(), 1); !errors.Is(err, domain.ErrConflict) {
+		t.Errorf("DeleteAuthorById() error = %v, want %v", err, domain.ErrConflict)
+	}
+}
+
+func TestSQLiteCreateBookRollsBackNewAuthors(t *testing.T) {
+	repository := newSQLiteBookRepository(t, newBook("The Go Programming Language", "9780134190440", 2015, "Alan Donovan"))
+	duplicate := newBook("Go", "9780134190440", 2016)
+	if _, err := repository.CreateBook(context.Background(), duplicate, []string{"Rob Pike"}); !errors.Is(err, domain.ErrDuplicateISBN) {
+		t.Fatalf("CreateBook() error = %v, want %v", err, domain.ErrDuplicateISBN)
+	}
+
+	authorRepository := NewSQLAuthorRepository(repository.DB, logger.NewNop())
+	if author, err := authorRepository.GetAuthorByName(context.Background(), "Rob Pike"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("GetAuthorByName() = %v, %v, want the author rolled back", author, err)
+	}
+}
+
+func TestSQLiteCreateBookWithNewAuthors(t *testing.T) {
+	repository := newSQLiteBookRepository(t, newBook("The C Programming Language", "9780131103627", 1988, "Dennis Ritchie"))
+	names := []string{"Zed Shaw", "Dennis Ritchie", "Alan Donovan", "Brian Kernighan"}
+	book, err := repository.CreateBook(context.Background(), newBook("Go", "9780134190440", 2015), names)
+	if err != nil {
+		t.Fatalf("CreateBook() error = %v", err)
+	}
+
+	authorRepository := NewSQLAuthorRepository(repository.DB, logger.NewNop())
+	for index, author := range book.Authors {
+		if author.Name != names[index] {
+			t.Errorf("author %d = %q, want %q", index, author.Name, names[index])
+		}
+		stored, err := authorRepository.GetAuthorById(context.Background(), author.Id)
+		if err != nil || stored.Name != author.Name {
+			t.Errorf("GetAuthorById(%d) = %v, %v, want %q", author.Id, stored, err, author.Name)
+		}
+	}
+}
+
+func TestSQLiteDeleteAuthorOnOneConnection(t *testing.T) {
+	repository := newSQLiteBookRepository(t)
+	authorRepository := NewSQLAuthorRepository(repository.DB, logger.NewNop())
+	author, err := authorRepository.CreateAuthor(context.Background(), domain.Author{Name: "Rob Pike"})
+	if err != nil {
+		t.Fatalf("CreateAuthor() error = %v", err)
+	}
+
+	// A lookup through the pool would wait for the connection held by the
+	// transaction until the deadline.
+	repository.DB.SetMaxOpenConns(1)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if _, err := authorRepository.DeleteAuthorById(ctx, author.Id); err != nil {
+		t.Fatalf("DeleteAuthorById() error = %v", err)
+	}
+	if _, err := authorRepository.DeleteAuthorById(ctx, author.Id); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("DeleteAuthorById() of a deleted author error = %v, want %v", err, domain.ErrNotFound)
+	}
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/hoaibao/book-management/pkg/logger"
+)
+
+// sqlLogger logs the statements run by the SQL repositories and their
+// errors, to both the console and the log file. Entries go to the logger of
+// the context when there is one, so they carry the id of the request.
+type sqlLogger struct {
+	logger logger.Logger
+}
+
+func (l sqlLogger) checkError(ctx context.Context, err error, message string) {
+	if err != nil {
+		log := logger.FromContext(ctx, l.logger)
+		log.ConsoleLogger.Errorw(message, "error", err)
+		log.FileLogger.Errorw(message, "error", err)
+	}
+}
+
+func (l sqlLogger) logMessage(ctx context.Context, args ...interface{}) {
+	log := logger.FromContext(ctx, l.logger)
+	log.ConsoleLogger.Infoln(args...)
+	log.FileLogger.Infoln(args...)
+}
+
+func (l sqlLogger) logStatement(ctx context.Context, statement string, args []interface{}) {
+	log := logger.FromContext(ctx, l.logger)
+	log.ConsoleLogger.Infow("SQL", "statement", statement, "args", args)
+	log.FileLogger.Infow("SQL", "statement", statement, "args", args)
+}
+
+type statementBuilder interface {
+	Build() (string, []interface{}, error)
+}
+
+// execStatement builds and runs a statement that returns no rows and
+// reports how many rows it affected.
+func (l sqlLogger) execStatement(ctx context.Context, db queryExecer, statement statementBuilder, failMessage string) (int64, error) {
+	sqlStatement, args, err := statement.Build()
+	if err != nil {
+		l.checkError(ctx, err, "Can't build query")
+		return 0, err
+	}
+	l.logStatement(ctx, sqlStatement, args)
+	result, err := db.ExecContext(ctx, sqlStatement, args...)
+	if err != nil {
+		l.checkError(ctx, err, failMessage)
+		return 0, translateError(err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		l.checkError(ctx, err, "Can't get number of rows affected")
+		return 0, err
+	}
+	return rows, nil
+}
@@ -9,10 +9,20 @@ func SetMainRouter() *mux.Router {
 	return mux.NewRouter()
 }
 
+func SetHealthRouter(healthHandler *handler.HealthHandler, mainRouter *mux.Router) {
+	mainRouter.HandleFunc("/healthz", healthHandler.LiveHandler).Methods("GET")
+	mainRouter.HandleFunc("/readyz", healthHandler.ReadyHandler).Methods("GET")
+}
+
 func SetBookRouter(bookHandler *handler.BookHandler, mainRouter *mux.Router) {
 	bookRouter := mainRouter.PathPrefix("/api/v3/books").Subrouter()
 
 	bookRouter.HandleFunc("", bookHandler.GetAllBooksHandler).Methods("GET")
+	bookRouter.HandleFunc("/trash", bookHandler.GetDeletedBooksHandler).Methods("GET")
+	bookRouter.HandleFunc("/{bookId}/restore", bookHandler.RestoreBookByIdHandler).Methods("POST")
+	bookRouter.HandleFunc("/{bookId}/history", bookHandler.GetBookHistoryHandler).Methods("GET")
+	bookRouter.HandleFunc("/{bookId}/history/{revision}", bookHandler.GetBookRevisionHandler).Methods("GET")
+	bookRouter.HandleFunc("/{bookId}/history/{revision}/revert", bookHandler.RevertBookHandler).Methods("POST")
 	bookRouter.HandleFunc("/{bookId}", bookHandler.GetBookByIdHandler).Methods("GET")
 	bookRouter.HandleFunc("", bookHandler.CreateBookHandler).Methods("POST")
 	bookRouter.HandleFunc("/{bookId}", bookHandler.DeleteBookByIdHandler).Methods("DELETE")
@@ -20,3 +30,14 @@ func SetBookRouter(bookHandler *handler.BookHandler, mainRouter *mux.Router) {
 	bookRouter.HandleFunc("/{bookId}", bookHandler.UpdateBookByIdHandler).Methods("PUT")
 	bookRouter.HandleFunc("", bookHandler.UpdateMultipleBookByIdHandler).Methods("PUT")
 }
+
+func SetAuthorRouter(authorHandler *handler.AuthorHandler, mainRouter *mux.Router) {
+	authorRouter := mainRouter.PathPrefix("/api/v3/authors").Subrouter()
+
+	authorRouter.HandleFunc("", authorHandler.GetAllAuthorsHandler).Methods("GET")
+	authorRouter.HandleFunc("/{authorId}", authorHandler.GetAuthorByIdHandler).Methods("GET")
+	authorRouter.HandleFunc("/{authorId}/books", authorHandler.GetBooksByAuthorIdHandler).Methods("GET")
+	authorRouter.HandleFunc("", authorHandler.CreateAuthorHandler).Methods("POST")
+	authorRouter.HandleFunc("/{authorId}", authorHandler.UpdateAuthorByIdHandler).Methods("PUT")
+	authorRouter.HandleFunc("/{authorId}", authorHandler.DeleteAuthorByIdHandler).Methods("DELETE")
+}
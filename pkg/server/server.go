@@ -0,0 +1,107 @@
+// Package server runs the HTTP server and shuts it down gracefully.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/hoaibao/book-management/pkg/middleware"
+)
+
+type Config struct {
+	Addr string
+	// RequestTimeout is the deadline of the context of each request.
+	RequestTimeout    time.Duration
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	// ShutdownDelay is how long the server keeps serving once asked to
+	// stop, while ShuttingDown reports true, so that load balancers polling
+	// the readiness check stop sending it requests.
+	ShutdownDelay time.Duration
+	// ShutdownTimeout is how long in-flight requests are given to finish
+	// once the server stops accepting connections.
+	ShutdownTimeout time.Duration
+	MaxHeaderBytes  int
+	MaxBodyBytes    int
+}
+
+type Server struct {
+	httpServer      *http.Server
+	shutdownDelay   time.Duration
+	shutdownTimeout time.Duration
+	closers         []func() error
+	shuttingDown    atomic.Bool
+}
+
+func New(config Config, handler http.Handler) *Server {
+	handler = middleware.Timeout(config.RequestTimeout)(handler)
+	handler = middleware.MaxBodySize(int64(config.MaxBodyBytes))(handler)
+	return &Server{
+		httpServer: &http.Server{
+			Addr:              config.Addr,
+			Handler:           handler,
+			ReadHeaderTimeout: config.ReadHeaderTimeout,
+			ReadTimeout:       config.ReadTimeout,
+			WriteTimeout:      config.WriteTimeout,
+			IdleTimeout:       config.IdleTimeout,
+			MaxHeaderBytes:    config.MaxHeaderBytes,
+		},
+		shutdownDelay:   config.ShutdownDelay,
+		shutdownTimeout: config.ShutdownTimeout,
+	}
+}
+
+// ShuttingDown reports whether the server has been asked to stop.
+func (s *Server) ShuttingDown() bool {
+	return s.shuttingDown.Load()
+}
+
+// OnShutdown registers fn to run once the server has stopped, after the
+// in-flight requests are done. Functions run in the order they were
+// registered, so the resources used by the others, like the logger, should
+// come last.
+func (s *Server) OnShutdown(fn func() error) {
+	s.closers = append(s.closers, fn)
+}
+
+// Run serves until ctx is done, keeps serving for the shutdown delay, then
+// stops accepting connections and waits up to the shutdown timeout for the
+// in-flight requests before closing the remaining connections. It returns
+// once the OnShutdown functions have run.
+func (s *Server) Run(ctx context.Context) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		return errors.Join(err, s.close())
+	case <-ctx.Done():
+	}
+	s.shuttingDown.Store(true)
+	time.Sleep(s.shutdownDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+	err := s.httpServer.Shutdown(shutdownCtx)
+	if err != nil {
+		err = fmt.Errorf("requests still running after %s: %w", s.shutdownTimeout, err)
+		s.httpServer.Close()
+	}
+	return errors.Join(err, s.close())
+}
+
+func (s *Server) close() error {
+	var errs []error
+	for _, closer := range s.closers {
+		errs = append(errs, closer())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// client doesn't keep connections alive, so that the only connections the
+// server waits for are those of requests in flight.
+var client = &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+
+// freeAddr returns a local address nothing listens on.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return listener.Addr().String()
+}
+
+// start runs server until the returned cancel is called, and waits for it
+// to answer on /ready.
+func start(t *testing.T, server *Server, addr string) (context.CancelFunc, <-chan error) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() { runErr <- server.Run(ctx) }()
+
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		response, err := client.Get("http://" + addr + "/ready")
+		if err == nil {
+			response.Body.Close()
+			return cancel, runErr
+		}
+		if time.Now().After(deadline) {
+			cancel()
+			t.Fatalf("server not listening: %v", err)
+		}
+	}
+}
+
+// slowHandler answers /slow once release is closed, and every other path
+// right away.
+func slowHandler(started chan<- struct{}, release <-chan struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			close(started)
+			<-release
+		}
+		io.WriteString(w, "done")
+	})
+}
+
+func TestRunWaitsForInFlightRequests(t *testing.T) {
+	addr := freeAddr(t)
+	started, release := make(chan struct{}), make(chan struct{})
+	server := New(Config{Addr: addr, ShutdownTimeout: 5 * time.Second}, slowHandler(started, release))
+	var closed []string
+	server.OnShutdown(func() error { closed = append(closed, "database"); return nil })
+	server.OnShutdown(func() error { closed = append(closed, "logger"); return nil })
+	cancel, runErr := start(t, server, addr)
+
+	body := make(chan string, 1)
+	go func() {
+		response, err := client.Get("http://" + addr + "/slow")
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer response.Body.Close()
+		data, _ := io.ReadAll(response.Body)
+		body <- string(data)
+	}()
+	<-started
+	cancel()
+
+	select {
+	case err := <-runErr:
+		t.Fatalf("Run() returned %v before the request was answered", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+	if got := <-body; got != "done" {
+		t.Errorf("in-flight request got %q, want %q", got, "done")
+	}
+	if err := <-runErr; err != nil {
+		t.Errorf("Run() error = %v", err)
+	}
+	if want := []string{"database", "logger"}; !reflect.DeepEqual(closed, want) {
+		t.Errorf("closed %v, want %v", closed, want)
+	}
+	if _, err := client.Get("http://" + addr + "/ready"); err == nil {
+		t.Error("server still accepts connections after Run returned")
+	}
+}
+
+func TestRunShutdownTimeout(t *testing.T) {
+	addr := freeAddr(t)
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	server := New(Config{Addr: addr, ShutdownTimeout: 50 * time.Millisecond}, slowHandler(started, release))
+	closed := false
+	server.OnShutdown(func() error { closed = true; return nil })
+	cancel, runErr := start(t, server, addr)
+
+	go client.Get("http://" + addr + "/slow")
+	<-started
+	cancel()
+
+	err := <-runErr
+	if err == nil || !strings.Contains(err.Error(), "requests still running") {
+		t.Errorf("Run() error = %v, want the requests still running reported", err)
+	}
+	if !closed {
+		t.Error("OnShutdown functions didn't run")
+	}
+}
+
+func TestRunListenError(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	server := New(Config{Addr: listener.Addr().String(), ShutdownTimeout: time.Second}, http.NotFoundHandler())
+	closed := false
+	server.OnShutdown(func() error { closed = true; return nil })
+	if err := server.Run(context.Background()); err == nil {
+		t.Error("Run() on an address in use succeeded")
+	}
+	if !closed {
+		t.Error("OnShutdown functions didn't run")
+	}
+}
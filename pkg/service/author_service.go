@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hoaibao/book-management/pkg/domain"
+	"github.com/hoaibao/book-management/pkg/repository"
+)
+
+type AuthorService struct {
+	authorRepository repository.AuthorRepository
+	bookRepository   repository.BookRepository
+}
+
+func NewAuthorService(authorRepository repository.AuthorRepository, bookRepository repository.BookRepository) *AuthorService {
+	return &AuthorService{
+		authorRepository: authorRepository,
+		bookRepository:   bookRepository,
+	}
+}
+
+func (s *AuthorService) GetAllAuthors(ctx context.Context) ([]domain.Author, error) {
+	return s.authorRepository.GetAllAuthors(ctx)
+}
+
+func (s *AuthorService) GetAuthorById(ctx context.Context, id int) (domain.Author, error) {
+	return s.authorRepository.GetAuthorById(ctx, id)
+}
+
+func (s *AuthorService) GetBooksByAuthorId(ctx context.Context, id int) ([]domain.Book, error) {
+	if _, err := s.authorRepository.GetAuthorById(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.bookRepository.GetBooksByAuthorId(ctx, id)
+}
+
+func (s *AuthorService) CreateAuthor(ctx context.Context, name string, birthDay domain.Date) (domain.Author, error) {
+	author, err := s.validateAuthor(ctx, 0, name, birthDay)
+	if err != nil {
+		return domain.Author{}, err
+	}
+	return s.authorRepository.CreateAuthor(ctx, author)
+}
+
+func (s *AuthorService) UpdateAuthorById(ctx context.Context, id int, name string, birthDay domain.Date) (domain.Author, error) {
+	if _, err := s.authorRepository.GetAuthorById(ctx, id); err != nil {
+		return domain.Author{}, err
+	}
+	author, err := s.validateAuthor(ctx, id, name, birthDay)
+	if err != nil {
+		return domain.Author{}, err
+	}
+	return s.authorRepository.UpdateAuthorById(ctx, id, author)
+}
+
+func (s *AuthorService) DeleteAuthorById(ctx context.Context, id int) (domain.Author, error) {
+	return s.authorRepository.DeleteAuthorById(ctx, id)
+}
+
+// validateAuthor checks the fields of the author with the given id, 0 for a
+// new author. Names are unique because books link authors by name; the
+// unique constraint on author.name catches the ones created concurrently.
+func (s *AuthorService) validateAuthor(ctx context.Context, id int, name string, birthDay domain.Date) (domain.Author, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return domain.Author{}, domain.NewValidationError("name", "must not be empty")
+	}
+	if birthDay.After(time.Now()) {
+		return domain.Author{}, domain.NewValidationError("birthDay", "must not be in the future")
+	}
+
+	existAuthor, err := s.authorRepository.GetAuthorByName(ctx, name)
+	switch {
+	case err == nil && existAuthor.Id != id:
+		return domain.Author{}, fmt.Errorf("%w: author %q already exists", domain.ErrConflict, name)
+	case err != nil && !errors.Is(err, domain.ErrNotFound):
+		return domain.Author{}, err
+	}
+
+	return domain.Author{
+		Name:     name,
+		BirthDay: birthDay,
+	}, nil
+}
@@ -1,7 +1,14 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/hoaibao/book-management/pkg/domain"
+	"github.com/hoaibao/book-management/pkg/isbn"
 	"github.com/hoaibao/book-management/pkg/repository"
 )
 
@@ -15,15 +22,56 @@ func NewBookService(bookRepository repository.BookRepository) *BookService {
 	}
 }
 
-func (s *BookService) GetAllBooks(isbn, author, fromValue, toValue string) ([]domain.Book, error) {
-	return s.bookRepository.GetAllBooks(isbn, author, fromValue, toValue)
+func (s *BookService) GetAllBooks(ctx context.Context, filter domain.BookFilter) (domain.BookPage, error) {
+	if err := validateInteger("from", filter.From); err != nil {
+		return domain.BookPage{}, err
+	}
+	if err := validateInteger("to", filter.To); err != nil {
+		return domain.BookPage{}, err
+	}
+	if err := validatePage(&filter); err != nil {
+		return domain.BookPage{}, err
+	}
+	if filter.ISBN != "" {
+		canonicalISBN, err := parseISBN(filter.ISBN)
+		if err != nil {
+			return domain.BookPage{}, err
+		}
+		filter.ISBN = canonicalISBN
+	}
+	// A search without terms matches nothing, whatever the storage.
+	if filter.Query != "" && len(domain.SearchTerms(filter.Query)) == 0 {
+		return domain.BookPage{Books: []domain.Book{}, Limit: filter.Limit, Offset: filter.Offset}, nil
+	}
+
+	books, total, err := s.bookRepository.GetAllBooks(ctx, filter)
+	if err != nil {
+		return domain.BookPage{}, err
+	}
+	return domain.BookPage{
+		Books:  books,
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	}, nil
 }
 
-func (s *BookService) GetBookById(id int) (domain.Book, error) {
-	return s.bookRepository.GetBookById(id)
+func (s *BookService) GetBookById(ctx context.Context, id int) (domain.Book, error) {
+	return s.bookRepository.GetBookById(ctx, id)
 }
 
-func (s *BookService) CreateBook(name, isbn string, author []string, publishYear int) (domain.Book, error) {
+func (s *BookService) CreateBook(ctx context.Context, name, isbnValue string, author []string, publishYear int) (domain.Book, error) {
+	if strings.TrimSpace(name) == "" {
+		return domain.Book{}, domain.NewValidationError("name", "must not be empty")
+	}
+	canonicalISBN, err := parseISBN(isbnValue)
+	if err != nil {
+		return domain.Book{}, err
+	}
+	if err := validateAuthors(author); err != nil {
+		return domain.Book{}, err
+	}
+
 	authorObj := []domain.Author{}
 
 	for _, authorName := range author {
@@ -31,19 +79,156 @@ func (s *BookService) CreateBook(name, isbn string, author []string, publishYear
 	}
 
 	book := domain.Book{
-		ISBN:        isbn,
+		ISBN:        canonicalISBN,
 		Name:        name,
 		Authors:     authorObj,
 		PublishYear: publishYear,
 	}
 
-	return s.bookRepository.CreateBook(book, author)
+	return s.bookRepository.CreateBook(ctx, book, author)
 }
 
-func (s *BookService) DeleteBookById(bookId int) (domain.Book, error) {
-	return s.bookRepository.DeleteBookById(bookId)
+// DeleteBookById deletes the book if it is at version, or at any version
+// when version is 0.
+func (s *BookService) DeleteBookById(ctx context.Context, bookId int, version int) (domain.Book, error) {
+	return s.bookRepository.DeleteBookById(ctx, bookId, version)
 }
 
-func (s *BookService) UpdateBookById(bookId int, bookData map[string][]string) (domain.Book, error) {
-	return s.bookRepository.UpdateBookById(bookId, bookData)
+// UpdateBookById checks version like DeleteBookById.
+func (s *BookService) UpdateBookById(ctx context.Context, bookId int, bookData map[string][]string, version int) (domain.Book, error) {
+	for key, value := range bookData {
+		switch key {
+		case "name":
+			if len(value) == 0 || strings.TrimSpace(value[0]) == "" {
+				return domain.Book{}, domain.NewValidationError(key, "must not be empty")
+			}
+		case "isbn":
+			if len(value) == 0 {
+				return domain.Book{}, domain.NewValidationError(key, "must not be empty")
+			}
+			canonicalISBN, err := parseISBN(value[0])
+			if err != nil {
+				return domain.Book{}, err
+			}
+			bookData[key] = []string{canonicalISBN}
+		case "publishYear":
+			if len(value) == 0 || value[0] == "" {
+				return domain.Book{}, domain.NewValidationError(key, "must not be empty")
+			}
+			if err := validateInteger(key, value[0]); err != nil {
+				return domain.Book{}, err
+			}
+		case "author":
+			if err := validateAuthors(value); err != nil {
+				return domain.Book{}, err
+			}
+		default:
+			return domain.Book{}, domain.NewValidationError(key, "unknown field")
+		}
+	}
+	return s.bookRepository.UpdateBookById(ctx, bookId, bookData, version)
+}
+
+// GetBookHistory returns the revisions of a book, each with the changes
+// made since the revision before it.
+func (s *BookService) GetBookHistory(ctx context.Context, bookId int) ([]domain.BookRevision, error) {
+	revisions, err := s.bookRepository.GetBookHistory(ctx, bookId)
+	if err != nil {
+		return nil, err
+	}
+	previous := domain.Book{}
+	for i := range revisions {
+		revisions[i].Changes = domain.DiffBooks(previous, revisions[i].Book)
+		previous = revisions[i].Book
+	}
+	return revisions, nil
+}
+
+// GetBookRevision returns a revision of a book with the changes made since
+// revision from, or since the revision before it when from is 0.
+func (s *BookService) GetBookRevision(ctx context.Context, bookId, revision, from int) (domain.BookRevision, error) {
+	bookRevision, err := s.bookRepository.GetBookRevision(ctx, bookId, revision)
+	if err != nil {
+		return domain.BookRevision{}, err
+	}
+	if from == 0 {
+		from = revision - 1
+	}
+
+	previous := domain.Book{}
+	if from > 0 {
+		fromRevision, err := s.bookRepository.GetBookRevision(ctx, bookId, from)
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.BookRevision{}, domain.NewValidationError("from", "unknown revision")
+		}
+		if err != nil {
+			return domain.BookRevision{}, err
+		}
+		previous = fromRevision.Book
+	}
+	bookRevision.Changes = domain.DiffBooks(previous, bookRevision.Book)
+	return bookRevision, nil
+}
+
+// RevertBook updates a book back to the state recorded in revision. The
+// revert is recorded as a new revision. version is checked like in
+// UpdateBookById. Authors renamed since the revision stay linked under
+// their new name.
+func (s *BookService) RevertBook(ctx context.Context, bookId, revision, version int) (domain.Book, error) {
+	bookRevision, err := s.bookRepository.GetBookRevision(ctx, bookId, revision)
+	if err != nil {
+		return domain.Book{}, err
+	}
+	return s.bookRepository.RevertBookById(ctx, bookId, bookRevision.Book, version)
+}
+
+// parseISBN returns the canonical form of an ISBN-10 or ISBN-13.
+func parseISBN(value string) (string, error) {
+	if strings.TrimSpace(value) == "" {
+		return "", domain.NewValidationError("isbn", "must not be empty")
+	}
+	canonicalISBN, err := isbn.Parse(value)
+	if err != nil {
+		return "", domain.NewValidationError("isbn", err.Error())
+	}
+	return canonicalISBN, nil
+}
+
+func (s *BookService) GetDeletedBooks(ctx context.Context) ([]domain.Book, error) {
+	return s.bookRepository.GetDeletedBooks(ctx)
+}
+
+func (s *BookService) RestoreBookById(ctx context.Context, bookId int) (domain.Book, error) {
+	return s.bookRepository.RestoreBookById(ctx, bookId)
+}
+
+// PurgeDeletedBooks removes the books that have been in the trash for
+// longer than retention.
+func (s *BookService) PurgeDeletedBooks(ctx context.Context, retention time.Duration) (int, error) {
+	if retention < 0 {
+		return 0, domain.NewValidationError("retention", "must not be negative")
+	}
+	return s.bookRepository.PurgeDeletedBooks(ctx, time.Now().Add(-retention))
+}
+
+func validateInteger(field, value string) error {
+	if value == "" {
+		return nil
+	}
+	if _, err := strconv.Atoi(value); err != nil {
+		return domain.NewValidationError(field, "must be an integer")
+	}
+	return nil
+}
+
+func validateAuthors(authors []string) error {
+	if len(authors) == 0 {
+		return domain.NewValidationError("author", "must contain at least one author")
+	}
+	for _, authorName := range authors {
+		if strings.TrimSpace(authorName) == "" {
+			return domain.NewValidationError("author", "must not contain empty names")
+		}
+	}
+	return nil
 }
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/hoaibao/book-management/pkg/domain"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// cursor is the position of a page. Clients only see it base64 encoded and
+// must pass it back unchanged.
+type cursor struct {
+	Offset int `json:"o"`
+}
+
+func EncodeCursor(offset int) string {
+	data, _ := json.Marshal(cursor{Offset: offset})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(value string) (int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return 0, domain.NewValidationError("cursor", "is invalid")
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.Offset < 0 {
+		return 0, domain.NewValidationError("cursor", "is invalid")
+	}
+	return c.Offset, nil
+}
+
+func validatePage(filter *domain.BookFilter) error {
+	if filter.Limit == 0 {
+		filter.Limit = DefaultPageSize
+	}
+	if filter.Limit < 0 || filter.Limit > MaxPageSize {
+		return domain.NewValidationError("limit", "must be between 1 and 100")
+	}
+	if filter.Offset < 0 {
+		return domain.NewValidationError("offset", "must not be negative")
+	}
+	return nil
+}
+
+var sortableFields = map[string]bool{
+	domain.SortById:          true,
+	domain.SortByName:        true,
+	domain.SortByISBN:        true,
+	domain.SortByPublishYear: true,
+	domain.SortByAuthor:      true,
+}
+
+// ParseSort reads a comma separated list of fields such as
+// "-publishYear,name", where a leading "-" sorts in descending order.
+func ParseSort(value string) ([]domain.SortField, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var sortFields []domain.SortField
+	seen := make(map[string]bool)
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		sortField := domain.SortField{Field: strings.TrimPrefix(field, "-"), Desc: strings.HasPrefix(field, "-")}
+		if !sortableFields[sortField.Field] {
+			return nil, domain.NewValidationError("sort", "unknown field \""+sortField.Field+"\", expected one of id, name, isbn, publishYear, author")
+		}
+		if seen[sortField.Field] {
+			return nil, domain.NewValidationError("sort", "field \""+sortField.Field+"\" is repeated")
+		}
+		seen[sortField.Field] = true
+		sortFields = append(sortFields, sortField)
+	}
+	return sortFields, nil
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/hoaibao/book-management/pkg/domain"
+)
+
+func TestCursor(t *testing.T) {
+	for _, offset := range []int{0, 1, 20, 12345} {
+		got, err := DecodeCursor(EncodeCursor(offset))
+		if err != nil || got != offset {
+			t.Errorf("DecodeCursor(EncodeCursor(%d)) = %d, %v", offset, got, err)
+		}
+	}
+}
+
+func TestDecodeInvalidCursor(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{name: "not base64", cursor: "not a cursor!"},
+		{name: "not json", cursor: "bm90IGpzb24"},
+		{name: "negative offset", cursor: EncodeCursor(-1)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := DecodeCursor(test.cursor)
+			var validationErr *domain.ValidationError
+			if !errors.As(err, &validationErr) || validationErr.Field != "cursor" {
+				t.Errorf("DecodeCursor(%q) error = %v, want a cursor validation error", test.cursor, err)
+			}
+		})
+	}
+}
+
+func TestValidatePage(t *testing.T) {
+	tests := []struct {
+		name      string
+		filter    domain.BookFilter
+		wantLimit int
+		field     string
+	}{
+		{name: "default limit", filter: domain.BookFilter{}, wantLimit: DefaultPageSize},
+		{name: "limit", filter: domain.BookFilter{Limit: 5, Offset: 10}, wantLimit: 5},
+		{name: "largest limit", filter: domain.BookFilter{Limit: MaxPageSize}, wantLimit: MaxPageSize},
+		{name: "limit too large", filter: domain.BookFilter{Limit: MaxPageSize + 1}, field: "limit"},
+		{name: "negative limit", filter: domain.BookFilter{Limit: -1}, field: "limit"},
+		{name: "negative offset", filter: domain.BookFilter{Offset: -1}, field: "offset"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validatePage(&test.filter)
+			if test.field != "" {
+				var validationErr *domain.ValidationError
+				if !errors.As(err, &validationErr) || validationErr.Field != test.field {
+					t.Fatalf("validatePage() error = %v, want a %s validation error", err, test.field)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("validatePage() error = %v", err)
+			}
+			if test.filter.Limit != test.wantLimit {
+				t.Errorf("limit = %d, want %d", test.filter.Limit, test.wantLimit)
+			}
+		})
+	}
+}
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  []domain.SortField
+	}{
+		{name: "empty", value: ""},
+		{name: "ascending", value: "name", want: []domain.SortField{{Field: domain.SortByName}}},
+		{name: "descending", value: "-publishYear", want: []domain.SortField{{Field: domain.SortByPublishYear, Desc: true}}},
+		{
+			name:  "several fields",
+			value: "author, -isbn,id",
+			want:  []domain.SortField{{Field: domain.SortByAuthor}, {Field: domain.SortByISBN, Desc: true}, {Field: domain.SortById}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseSort(test.value)
+			if err != nil {
+				t.Fatalf("ParseSort(%q) error = %v", test.value, err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("ParseSort(%q) = %v, want %v", test.value, got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseSortRejectsFields(t *testing.T) {
+	for _, value := range []string{"price", "publish_year", "name,", "--name", "name,-name", "b.name; DROP TABLE book"} {
+		_, err := ParseSort(value)
+		var validationErr *domain.ValidationError
+		if !errors.As(err, &validationErr) || validationErr.Field != "sort" {
+			t.Errorf("ParseSort(%q) error = %v, want a sort validation error", value, err)
+		}
+	}
+}